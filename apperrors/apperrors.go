@@ -0,0 +1,116 @@
+package apperrors
+
+import (
+	"filmservice/models"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+	KindUnsupportedMediaType
+	KindTooLarge
+)
+
+var statuses = map[Kind]int{
+	KindInternal:             http.StatusInternalServerError,
+	KindValidation:           http.StatusBadRequest,
+	KindUnauthorized:         http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindTooLarge:             http.StatusRequestEntityTooLarge,
+}
+
+// Error is a domain error whose message is safe to show to clients. The cause, if any,
+// is only meant for logs.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []models.FieldError
+	cause   error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors of the same kind and message, so sentinels still match after Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Message == e.Message
+}
+
+// Status is the HTTP status the error is rendered with.
+func (e *Error) Status() int {
+	return statuses[e.Kind]
+}
+
+// Wrap returns a copy of the error that keeps cause for logs and errors.Is.
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.cause = cause
+
+	return &wrapped
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation reports invalid input, optionally pointing at the offending fields.
+func Validation(message string, fields ...models.FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+func UnsupportedMediaType(message string) *Error {
+	return &Error{Kind: KindUnsupportedMediaType, Message: message}
+}
+
+func TooLarge(message string) *Error {
+	return &Error{Kind: KindTooLarge, Message: message}
+}
+
+// Field is a shorthand for a single field error.
+func Field(field string, message string) models.FieldError {
+	return models.FieldError{Field: field, Message: message}
+}
+
+// InvalidField reports a single invalid field. The message should not repeat the field name,
+// the detail reads "<field> <message>".
+func InvalidField(field string, message string) *Error {
+	return Validation(field+" "+message, Field(field, message))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"filmservice/config"
+	logger2 "filmservice/logger"
+	"filmservice/migrations"
+	"filmservice/posters"
+	"filmservice/purge"
+	"filmservice/repositories"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const usage = `usage:
+  filmservice                   start the API server (applies pending migrations first)
+  filmservice migrate up        apply all pending migrations
+  filmservice migrate down      roll back the latest applied migration
+  filmservice migrate status    list migrations and when they were applied
+  filmservice gc-posters [-dry-run] [-grace 24h]
+                                delete stored posters no movie references anymore
+  filmservice purge [-retention 720h]
+                                remove movies, genres and users deleted longer ago than the retention`
+
+func runCommand(conn *pgxpool.Pool, migrator *migrations.Migrator, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrateCommand(migrator, args[1:])
+	case "gc-posters":
+		return runGcPostersCommand(conn, args[1:])
+	case "purge":
+		return runPurgeCommand(conn, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrateCommand(migrator *migrations.Migrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("migrate expects exactly one of up, down or status\n%s", usage)
+	}
+
+	c := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(c)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+
+		return nil
+	case "down":
+		reverted, err := migrator.Down(c)
+		if err != nil {
+			return err
+		}
+
+		if reverted == nil {
+			fmt.Println("no applied migrations")
+			return nil
+		}
+		fmt.Printf("reverted %04d_%s\n", reverted.Version, reverted.Name)
+
+		return nil
+	case "status":
+		statuses, err := migrator.Status(c)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+func runGcPostersCommand(conn *pgxpool.Pool, args []string) error {
+	flags := flag.NewFlagSet("gc-posters", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only list orphaned posters")
+	grace := flags.Duration("grace", config.Config.PosterGcGracePeriod, "keep posters uploaded more recently than this")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	c := context.Background()
+
+	store, err := newBlobStore(c)
+	if err != nil {
+		return err
+	}
+
+	sweeper := posters.NewSweeper(store, repositories.NewMoviesRepository(conn), logger2.GetLogger())
+
+	orphans, err := sweeper.Sweep(c, *grace, *dryRun)
+	for _, orphan := range orphans {
+		fmt.Printf("%s\t%d\t%s\n", orphan.Key, orphan.Size, orphan.ModTime.Format(time.RFC3339))
+	}
+	if err != nil {
+		return err
+	}
+
+	action := "deleted"
+	if *dryRun {
+		action = "would delete"
+	}
+	fmt.Printf("%s %d orphaned objects\n", action, len(orphans))
+
+	return nil
+}
+
+func runPurgeCommand(conn *pgxpool.Pool, args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	retention := flags.Duration("retention", config.Config.SoftDeleteRetention, "keep records deleted more recently than this")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	c := context.Background()
+
+	store, err := newBlobStore(c)
+	if err != nil {
+		return err
+	}
+
+	purger := purge.NewPurger(
+		repositories.NewMoviesRepository(conn),
+		repositories.NewGenresRepository(conn),
+		repositories.NewUsersRepository(conn),
+		store,
+		logger2.GetLogger(),
+	)
+
+	result, err := purger.Purge(c, *retention)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("purged %d movies, %d genres and %d users\n", result.Movies, result.Genres, result.Users)
+
+	return nil
+}
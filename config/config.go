@@ -5,8 +5,62 @@ import "time"
 var Config *MapConfig
 
 type MapConfig struct {
-	AppHost            string        `mapstructure:"APP_HOST"`
-	DbConnectionString string        `mapstructure:"DB_CONNECTION_STRING"`
-	JwtSecretKey       string        `mapstructure:"JWT_SECRET_KEY"`
-	JwtExpiresIn       time.Duration `mapstructure:"JWT_EXPIRE_DURATION"`
+	AppHost               string        `mapstructure:"APP_HOST"`
+	AppBaseUrl            string        `mapstructure:"APP_BASE_URL"`
+	TrustedProxies        []string      `mapstructure:"TRUSTED_PROXIES"`
+	DbConnectionString    string        `mapstructure:"DB_CONNECTION_STRING"`
+	JwtSecretKey          string        `mapstructure:"JWT_SECRET_KEY"`
+	JwtExpiresIn          time.Duration `mapstructure:"JWT_EXPIRE_DURATION"`
+	RefreshTokenExpiresIn time.Duration `mapstructure:"REFRESH_TOKEN_EXPIRE_DURATION"`
+
+	EmailVerificationExpiresIn time.Duration `mapstructure:"EMAIL_VERIFICATION_EXPIRE_DURATION"`
+	PasswordResetExpiresIn     time.Duration `mapstructure:"PASSWORD_RESET_EXPIRE_DURATION"`
+
+	MailerDriver string        `mapstructure:"MAILER_DRIVER"`
+	MailFrom     string        `mapstructure:"MAIL_FROM"`
+	MailLogPath  string        `mapstructure:"MAIL_LOG_PATH"`
+	SmtpHost     string        `mapstructure:"SMTP_HOST"`
+	SmtpPort     int           `mapstructure:"SMTP_PORT"`
+	SmtpUsername string        `mapstructure:"SMTP_USERNAME"`
+	SmtpPassword string        `mapstructure:"SMTP_PASSWORD"`
+	SmtpTimeout  time.Duration `mapstructure:"SMTP_TIMEOUT"`
+
+	StorageDriver        string        `mapstructure:"STORAGE_DRIVER"`
+	StoragePath          string        `mapstructure:"STORAGE_PATH"`
+	StorageRedirect      bool          `mapstructure:"STORAGE_REDIRECT"`
+	StoragePresignExpiry time.Duration `mapstructure:"STORAGE_PRESIGN_EXPIRE_DURATION"`
+	S3Endpoint           string        `mapstructure:"S3_ENDPOINT"`
+	S3Region             string        `mapstructure:"S3_REGION"`
+	S3Bucket             string        `mapstructure:"S3_BUCKET"`
+	S3AccessKey          string        `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey          string        `mapstructure:"S3_SECRET_KEY"`
+	S3UseSSL             bool          `mapstructure:"S3_USE_SSL"`
+
+	PosterMaxBytes      int64         `mapstructure:"POSTER_MAX_BYTES"`
+	PosterGcInterval    time.Duration `mapstructure:"POSTER_GC_INTERVAL"`
+	PosterGcGracePeriod time.Duration `mapstructure:"POSTER_GC_GRACE_PERIOD"`
+
+	PurgeInterval       time.Duration `mapstructure:"PURGE_INTERVAL"`
+	SoftDeleteRetention time.Duration `mapstructure:"SOFT_DELETE_RETENTION"`
+
+	PasswordMinLength        int  `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireMixedCase bool `mapstructure:"PASSWORD_REQUIRE_MIXED_CASE"`
+	PasswordRequireDigit     bool `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol    bool `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+
+	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingOtlpEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOtlpInsecure bool    `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingServiceName  string  `mapstructure:"TRACING_SERVICE_NAME"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	HttpReadTimeout       time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HttpReadHeaderTimeout time.Duration `mapstructure:"HTTP_READ_HEADER_TIMEOUT"`
+	HttpWriteTimeout      time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	HttpIdleTimeout       time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	ShutdownDrainDelay    time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout       time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"filmservice/apperrors"
+	"filmservice/models"
+	"filmservice/repositories"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var auditEntities = []string{"movie", "genre", "user"}
+
+type AuditHandlers struct {
+	auditRepo *repositories.AuditRepository
+}
+
+func NewAuditHandlers(auditRepo *repositories.AuditRepository) *AuditHandlers {
+	return &AuditHandlers{
+		auditRepo: auditRepo,
+	}
+}
+
+// FindAll   	 godoc
+// @Summary      Get audit events
+// @Description  Lists changes of movies, genres and users, newest first. before and after hold the changed fields,
+// @Description  or the whole entity when it was created or purged.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param        actorId   query     int     false  "Id of the user who made the change"
+// @Param        entity    query     string  false  "Kind of the changed entity" Enums(movie, genre, user)
+// @Param        entityId  query     int     false  "Id of the changed entity"
+// @Param        action    query     string  false  "Action, e.g. create, update, delete, restore or purge"
+// @Param        from      query     string  false  "Earliest time, RFC 3339"
+// @Param        to        query     string  false  "Time before which the events happened, RFC 3339"
+// @Param        limit   query     int     false  "Page size (1-100, default 20)"
+// @Param        cursor  query     string  false  "nextCursor of the previous page"
+// @Param        offset  query     int     false  "Rows to skip, ignored when a cursor is given"
+// @Success      200  {object}  models.Page[models.AuditEvent] "OK"
+// @Failure      400  {object}  models.ApiError "Invalid filter or page parameters"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      500  {object}  models.ApiError
+// @Router       /audit [get]
+// @Security Bearer
+func (h *AuditHandlers) FindAll(c *gin.Context) {
+	filters, err := parseAuditFilters(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	events, err := h.auditRepo.FindAll(c, filters, page)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// parseAuditFilters reads the filter query parameters of the audit log.
+func parseAuditFilters(c *gin.Context) (models.AuditFilters, error) {
+	filters := models.AuditFilters{
+		Entity: c.Query("entity"),
+		Action: c.Query("action"),
+	}
+
+	if filters.Entity != "" && !slices.Contains(auditEntities, filters.Entity) {
+		return models.AuditFilters{}, apperrors.InvalidField("entity", "must be one of movie, genre, user")
+	}
+
+	var err error
+	filters.ActorId, err = parseOptionalInt(c.Query("actorId"))
+	if err != nil {
+		return models.AuditFilters{}, apperrors.InvalidField("actorId", "must be an integer")
+	}
+
+	filters.EntityId, err = parseOptionalInt(c.Query("entityId"))
+	if err != nil {
+		return models.AuditFilters{}, apperrors.InvalidField("entityId", "must be an integer")
+	}
+
+	filters.From, err = parseOptionalTime(c.Query("from"))
+	if err != nil {
+		return models.AuditFilters{}, apperrors.InvalidField("from", "must be an RFC 3339 time")
+	}
+
+	filters.To, err = parseOptionalTime(c.Query("to"))
+	if err != nil {
+		return models.AuditFilters{}, apperrors.InvalidField("to", "must be an RFC 3339 time")
+	}
+
+	if filters.From != nil && filters.To != nil && filters.From.After(*filters.To) {
+		return models.AuditFilters{}, apperrors.InvalidField("from", "must not be after to")
+	}
+
+	return filters, nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -1,33 +1,93 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"filmservice/apperrors"
 	"filmservice/config"
+	"filmservice/mailer"
+	"filmservice/metrics"
 	"filmservice/models"
 	"filmservice/repositories"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandlers struct {
-	usersRepo *repositories.UsersRepository
+	usersRepo      *repositories.UsersRepository
+	tokensRepo     *repositories.TokensRepository
+	userTokensRepo *repositories.UserTokensRepository
+	mailer         mailer.Mailer
 }
 
-func NewAuthHandlers(usersRepo *repositories.UsersRepository) *AuthHandlers {
-	return &AuthHandlers{usersRepo: usersRepo}
+func NewAuthHandlers(
+	usersRepo *repositories.UsersRepository,
+	tokensRepo *repositories.TokensRepository,
+	userTokensRepo *repositories.UserTokensRepository,
+	mailer mailer.Mailer,
+) *AuthHandlers {
+	return &AuthHandlers{
+		usersRepo:      usersRepo,
+		tokensRepo:     tokensRepo,
+		userTokensRepo: userTokensRepo,
+		mailer:         mailer,
+	}
 }
 
 type signInRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type signUpRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
 type signInResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
 }
 
 // SignIn   	 godoc
@@ -39,45 +99,561 @@ type signInResponse struct {
 // @Success      200  {object}  signInResponse "OK"
 // @Success      400  {object}  models.ApiError "Invalid request payload"
 // @Failure      401  {object}  models.ApiError "Invalid credentials"
+// @Failure      403  {object}  models.ApiError "Email is not verified"
 // @Failure      500  {object}  models.ApiError
 // @Router       /auth/signIn [post]
 func (h *AuthHandlers) SignIn(c *gin.Context) {
 	var request signInRequest
-	if err := c.BindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid request payload"))
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
 		return
 	}
 
 	user, err := h.usersRepo.FindByEmail(c, request.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		metrics.SignInFailures.WithLabelValues("unknown_email").Inc()
+		c.Error(apperrors.Unauthorized("Invalid credentials"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.Password))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.NewApiError("Invalid credentials"))
+		metrics.SignInFailures.WithLabelValues("wrong_password").Inc()
+		c.Error(apperrors.Unauthorized("Invalid credentials"))
 		return
 	}
 
-	claims := jwt.RegisteredClaims{
-		Subject:   strconv.Itoa(user.Id),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.Config.JwtExpiresIn)),
+	if user.EmailVerifiedAt == nil {
+		metrics.SignInFailures.WithLabelValues("email_not_verified").Inc()
+		c.Error(apperrors.Forbidden("Email is not verified"))
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.Config.JwtSecretKey))
+	response, session, err := issueTokens(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("Could not generate JWT token"))
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, signInResponse{
-		Token: tokenString,
+	err = h.tokensRepo.CreateSession(c, session)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SignUp   	 godoc
+// @Summary      Register a new account
+// @Description  Creates an unverified viewer account and mails a verification token to it. When the email is already registered, its owner is mailed instead and the response is the same, so that sign up cannot be used to find out which emails are registered.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body signUpRequest true "Sign up payload"
+// @Success      202  "Accepted"
+// @Failure      400  {object}  models.ApiError "Invalid request payload"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/signUp [post]
+func (h *AuthHandlers) SignUp(c *gin.Context) {
+	var request signUpRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		c.Error(apperrors.Validation("Name is required"))
+		return
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user := models.User{
+		Name:         request.Name,
+		Email:        request.Email,
+		PasswordHash: string(passwordHash),
+		Role:         models.RoleViewer,
+	}
+
+	user.Id, err = h.usersRepo.Create(c, user)
+	if errors.Is(err, repositories.ErrEmailTaken) {
+		err = h.notifyRegisteredEmail(c, request.Email)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Status(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.sendVerificationEmail(c, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// notifyRegisteredEmail mails the owner of an email somebody tried to sign up with: a new verification
+// token while the account is unverified, otherwise a notice to sign in or reset the password.
+func (h *AuthHandlers) notifyRegisteredEmail(c *gin.Context, email string) error {
+	user, err := h.usersRepo.FindByEmail(c, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The account was deleted since the sign up failed, and there is nobody left to tell.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return h.sendVerificationEmail(c, user)
+	}
+
+	return h.mailer.Send(c, mailer.Message{
+		To:      user.Email,
+		Subject: "Your FilmService account",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomebody tried to sign up with your email address, which already has an account. If it was you, sign in at %s, where you can also reset your password if you forgot it.\n\nIf it was not you, ignore this email.\n",
+			user.Name, config.Config.AppBaseUrl,
+		),
 	})
 }
 
+// VerifyEmail   	 godoc
+// @Summary      Verify email address
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body verifyEmailRequest true "Token from the verification email"
+// @Success      200  "OK"
+// @Failure      400  {object}  models.ApiError "Invalid or expired token"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/verifyEmail [post]
+func (h *AuthHandlers) VerifyEmail(c *gin.Context) {
+	var request verifyEmailRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	_, err := h.userTokensRepo.VerifyEmail(c, hashToken(request.Token))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ResendVerification   	 godoc
+// @Summary      Resend the verification email
+// @Description  Always succeeds so that it cannot be used to find out which emails are registered.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body resendVerificationRequest true "Account email"
+// @Success      202  "Accepted"
+// @Failure      400  {object}  models.ApiError "Invalid request payload"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/resendVerification [post]
+func (h *AuthHandlers) ResendVerification(c *gin.Context) {
+	var request resendVerificationRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.usersRepo.FindByEmail(c, request.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		err = h.sendVerificationEmail(c, user)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *AuthHandlers) sendVerificationEmail(c *gin.Context, user models.User) error {
+	token, err := h.issueUserToken(c, user, models.TokenPurposeEmailVerification, config.Config.EmailVerificationExpiresIn)
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(c, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your FilmService account",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address by opening the link below:\n%s\n\nOr use this token: %s\n\nThe link expires in %s.\n",
+			user.Name, appLink("verify-email", token), token, config.Config.EmailVerificationExpiresIn,
+		),
+	})
+}
+
+func (h *AuthHandlers) sendPasswordResetEmail(c *gin.Context, user models.User) error {
+	token, err := h.issueUserToken(c, user, models.TokenPurposePasswordReset, config.Config.PasswordResetExpiresIn)
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(c, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your FilmService password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomebody asked to reset the password of your account. Open the link below to choose a new one:\n%s\n\nOr use this token: %s\n\nThe link expires in %s and can be used once. If it was not you, ignore this email.\n",
+			user.Name, appLink("reset-password", token), token, config.Config.PasswordResetExpiresIn,
+		),
+	})
+}
+
+// issueUserToken stores a new single-use token for the user and returns its plain value for mailing.
+func (h *AuthHandlers) issueUserToken(c *gin.Context, user models.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = h.userTokensRepo.Create(c, models.UserToken{
+		UserId:    user.Id,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ForgotPassword   	 godoc
+// @Summary      Request a password reset email
+// @Description  Always succeeds so that it cannot be used to find out which emails are registered.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body forgotPasswordRequest true "Account email"
+// @Success      202  "Accepted"
+// @Failure      400  {object}  models.ApiError "Invalid request payload"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/forgotPassword [post]
+func (h *AuthHandlers) ForgotPassword(c *gin.Context) {
+	var request forgotPasswordRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.usersRepo.FindByEmail(c, request.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.sendPasswordResetEmail(c, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword   	 godoc
+// @Summary      Reset password with a token from the reset email
+// @Description  The token can be used once. Every session of the user is signed out.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body resetPasswordRequest true "Reset token and new password"
+// @Success      200  "OK"
+// @Failure      400  {object}  models.ApiError "Invalid payload or expired token"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/resetPassword [post]
+func (h *AuthHandlers) ResetPassword(c *gin.Context) {
+	var request resetPasswordRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userId, err := h.userTokensRepo.ResetPassword(c, hashToken(request.Token), string(passwordHash))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.tokensRepo.RevokeAllForUser(c, userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ChangePassword   	 godoc
+// @Summary      Change own password
+// @Description  Requires the current password. Every existing session is signed out and a new token pair is returned.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body changePasswordRequest true "Current and new password"
+// @Success      200  {object}  signInResponse "OK"
+// @Failure      400  {object}  models.ApiError "Invalid request payload"
+// @Failure      401  {object}  models.ApiError "Current password is wrong"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/changePassword [post]
+// @Security Bearer
+func (h *AuthHandlers) ChangePassword(c *gin.Context) {
+	var request changePasswordRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := h.usersRepo.FindById(c, c.GetInt("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.CurrentPassword))
+	if err != nil {
+		c.Error(apperrors.Unauthorized("Current password is wrong"))
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The current password was checked, so the change applies whatever else changed since the user was read.
+	user.PasswordHash = string(passwordHash)
+	user.Version = repositories.AnyVersion
+	_, err = h.usersRepo.ChangePassword(c, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.tokensRepo.RevokeAllForUser(c, user.Id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, session, err := issueTokens(user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.tokensRepo.CreateSession(c, session)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Refresh   	 godoc
+// @Summary      Exchange a refresh token for a new token pair
+// @Description  The presented refresh token is revoked. Presenting an already rotated token revokes every session of the user.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body refreshRequest true "Refresh payload"
+// @Success      200  {object}  signInResponse "OK"
+// @Failure      400  {object}  models.ApiError "Invalid request payload"
+// @Failure      401  {object}  models.ApiError "Invalid refresh token"
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/refresh [post]
+func (h *AuthHandlers) Refresh(c *gin.Context) {
+	var request refreshRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	session, err := h.tokensRepo.FindSessionByHash(c, hashToken(request.RefreshToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.Error(apperrors.Unauthorized("Invalid refresh token"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if session.RevokedAt != nil {
+		// A rotated token being replayed means it leaked, so end every session of its owner.
+		err = h.tokensRepo.RevokeAllForUser(c, session.UserId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Error(apperrors.Unauthorized("Invalid refresh token"))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		c.Error(apperrors.Unauthorized("Refresh token has expired"))
+		return
+	}
+
+	// A deleted user's session is invalid; other errors are failures of the lookup, not of the token.
+	user, err := h.usersRepo.FindById(c, session.UserId)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		c.Error(apperrors.Unauthorized("Invalid refresh token"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, next, err := issueTokens(user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.tokensRepo.Rotate(c, session.Id, next)
+	if errors.Is(err, repositories.ErrSessionRevoked) {
+		c.Error(apperrors.Unauthorized("Invalid refresh token"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SignOut   	 godoc
+// @Summary      Sign out
+// @Description  Revokes the presented access token and the refresh token issued with it.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Success      200  "OK"
+// @Failure      401  {object}  models.ApiError
+// @Failure      500  {object}  models.ApiError
+// @Router       /auth/signOut [post]
+// @Security Bearer
 func (h *AuthHandlers) SignOut(c *gin.Context) {
-	// TODO: Delete token
+	err := h.tokensRepo.RevokeAccessToken(c, c.GetString("tokenId"), c.GetTime("tokenExpiresAt"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
+
+// issueTokens signs a new access token for the user and generates the refresh token of its session.
+func issueTokens(user models.User) (signInResponse, models.Session, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(config.Config.JwtExpiresIn)
+
+	claims := models.Claims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   strconv.Itoa(user.Id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(config.Config.JwtSecretKey))
+	if err != nil {
+		return signInResponse{}, models.Session{}, err
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return signInResponse{}, models.Session{}, err
+	}
+
+	session := models.Session{
+		UserId:          user.Id,
+		TokenHash:       hashToken(refreshToken),
+		AccessTokenId:   claims.ID,
+		AccessExpiresAt: accessExpiresAt,
+		ExpiresAt:       now.Add(config.Config.RefreshTokenExpiresIn),
+	}
+
+	response := signInResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.Config.JwtExpiresIn.Seconds()),
+	}
+
+	return response, session, nil
+}
+
+// generateToken returns an opaque random token suitable for sending to a client.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// appLink builds a link to a page of the client application carrying a mailed token.
+func appLink(page string, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimSuffix(config.Config.AppBaseUrl, "/"), page, url.QueryEscape(token))
+}
+
+// hashToken is what gets stored for opaque tokens, so a database leak does not leak usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
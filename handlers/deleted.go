@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"filmservice/apperrors"
+	"filmservice/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseIncludeDeleted reads the includeDeleted query parameter of list endpoints, which only admins may set.
+func parseIncludeDeleted(c *gin.Context) (bool, error) {
+	value := c.Query("includeDeleted")
+	if value == "" {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, apperrors.InvalidField("includeDeleted", "must be true or false")
+	}
+
+	if includeDeleted && c.GetString("userRole") != models.RoleAdmin {
+		return false, apperrors.Forbidden("Only admins can list deleted records")
+	}
+
+	return includeDeleted, nil
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"net/http"
+	"testing"
+)
+
+func TestParseIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		role      string
+		want      bool
+		forbidden bool
+	}{
+		{name: "absent", query: "", role: models.RoleViewer},
+		{name: "false for users", query: "includeDeleted=false", role: models.RoleViewer},
+		{name: "admin", query: "includeDeleted=true", role: models.RoleAdmin, want: true},
+		{name: "user", query: "includeDeleted=true", role: models.RoleViewer, forbidden: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testContext(http.MethodGet, "/movies?"+tt.query, nil)
+			c.Set("userRole", tt.role)
+
+			includeDeleted, err := parseIncludeDeleted(c)
+
+			var appErr *apperrors.Error
+			if forbidden := errors.As(err, &appErr) && appErr.Kind == apperrors.KindForbidden; forbidden != tt.forbidden {
+				t.Fatalf("got error %v, forbidden %v", err, tt.forbidden)
+			}
+			if includeDeleted != tt.want {
+				t.Errorf("got %v, want %v", includeDeleted, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"filmservice/repositories"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes the row version, which clients send back in If-Match to update or delete the row.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.Itoa(version)))
+}
+
+// ifMatchVersions returns the versions listed in the If-Match header, and whether any version matches
+// because there is no header or it is "*". Weak or foreign entity tags can never match and are left out.
+func ifMatchVersions(c *gin.Context) ([]int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	versions := make([]int, 0)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, false
+}
+
+// matchesVersion checks the If-Match header against a version read before the write, so stale requests
+// fail early. Repositories check again while writing, see ifMatchVersion.
+func matchesVersion(c *gin.Context, version int) bool {
+	versions, anyVersion := ifMatchVersions(c)
+	return anyVersion || slices.Contains(versions, version)
+}
+
+// ifMatchVersion returns the version a write must still find once matchesVersion accepted the version
+// read: repositories.AnyVersion without an If-Match header, the version read otherwise.
+func ifMatchVersion(c *gin.Context, read int) int {
+	_, anyVersion := ifMatchVersions(c)
+	if anyVersion {
+		return repositories.AnyVersion
+	}
+
+	return read
+}
+
+func versionMismatch(c *gin.Context) {
+	c.Error(repositories.ErrVersionMismatch)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"filmservice/repositories"
+	"net/http"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		matches []int
+		misses  []int
+		write   int
+	}{
+		{header: "", matches: []int{1, 7}, write: repositories.AnyVersion},
+		{header: "*", matches: []int{1, 7}, write: repositories.AnyVersion},
+		{header: `"3"`, matches: []int{3}, misses: []int{2, 4}, write: 3},
+		{header: `"2", "3"`, matches: []int{2, 3}, misses: []int{1, 4}, write: 3},
+		{header: `"2",W/"3" , "4"`, matches: []int{2, 4}, misses: []int{3}, write: 3},
+		{header: `W/"3"`, misses: []int{3}, write: 3},
+		{header: `3`, misses: []int{3}, write: 3},
+		{header: `"abc", "0"`, misses: []int{0, 1}, write: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			c, _ := testContext(http.MethodPut, "/movies/1", nil)
+			c.Request.Header.Set("If-Match", tt.header)
+
+			for _, version := range tt.matches {
+				if !matchesVersion(c, version) {
+					t.Errorf("version %d does not match", version)
+				}
+			}
+			for _, version := range tt.misses {
+				if matchesVersion(c, version) {
+					t.Errorf("version %d matches", version)
+				}
+			}
+			if got := ifMatchVersion(c, 3); got != tt.write {
+				t.Errorf("ifMatchVersion(c, 3) = %d, want %d", got, tt.write)
+			}
+		})
+	}
+}
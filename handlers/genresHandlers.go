@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"filmservice/apperrors"
 	"filmservice/models"
 	"filmservice/repositories"
 	"net/http"
@@ -14,11 +15,11 @@ type GenreHandler struct {
 }
 
 type createGenreRequest struct {
-	Title string
+	Title string `json:"title" binding:"required,max=100"`
 }
 
 type updateGenreRequest struct {
-	Title string
+	Title string `json:"title" binding:"required,max=100"`
 }
 
 func NewGenreHandler(genreRepo *repositories.GenresRepository) *GenreHandler {
@@ -32,14 +33,33 @@ func NewGenreHandler(genreRepo *repositories.GenresRepository) *GenreHandler {
 // @Tags         genres
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Genre "OK"
+// @Param        limit   query     int     false  "Page size (1-100, default 20)"
+// @Param        cursor  query     string  false  "nextCursor of the previous page"
+// @Param        offset  query     int     false  "Rows to skip, ignored when a cursor is given"
+// @Param        includeDeleted  query  bool  false  "List deleted genres too, admins only"
+// @Success      200  {object}  models.Page[models.Genre] "OK"
+// @Failure      400  {object}  models.ApiError "Invalid page parameters"
+// @Failure      403  {object}  models.ApiError "Admin role required for includeDeleted"
 // @Failure      500  {object}  models.ApiError
 // @Router       /genres [get]
 // @Security Bearer
 func (h *GenreHandler) FindAll(c *gin.Context) {
-	genres, err := h.genresRepo.FindAll(c)
+	page, err := parsePageRequest(c)
 	if err != nil {
-		c.Status(http.StatusInternalServerError)
+		c.Error(err)
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	genres, err := h.genresRepo.FindAll(c, page, includeDeleted)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
 	c.JSON(http.StatusOK, genres)
@@ -52,6 +72,7 @@ func (h *GenreHandler) FindAll(c *gin.Context) {
 // @Produce      json
 // @Param        id   path      int  true  "Genre ID"
 // @Success      200  {object}  models.Genre "OK"
+// @Header       200  {string}  ETag "Version of the genre, for If-Match"
 // @Failure      400  {object}  models.ApiError "Invalid Genre Id"
 // @Failure      404  {object}  models.ApiError "Genre not found"
 // @Failure      500  {object}  models.ApiError
@@ -62,16 +83,17 @@ func (h *GenreHandler) FindById(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid Genre Id"))
+		c.Error(apperrors.Validation("Invalid Genre Id"))
 		return
 	}
 
 	genre, err := h.genresRepo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
+	setETag(c, genre.Version)
 	c.JSON(http.StatusOK, genre)
 }
 
@@ -83,15 +105,16 @@ func (h *GenreHandler) FindById(c *gin.Context) {
 // @Param        request body createGenreRequest true "Genre payload"
 // @Success      200  {object}  object{id=int} "OK"
 // @Failure      400  {object}  models.ApiError "Invalid payload"
+// @Failure      403  {object}  models.ApiError "Admin role required"
 // @Failure      500  {object}  models.ApiError
 // @Router       /genres [post]
 // @Security Bearer
 func (h *GenreHandler) Create(c *gin.Context) {
 	var request createGenreRequest
 
-	err := c.BindJSON(&request)
+	err := bindJSON(c, &request)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Could not bind JSON"))
+		c.Error(err)
 		return
 	}
 
@@ -101,7 +124,7 @@ func (h *GenreHandler) Create(c *gin.Context) {
 
 	id, err := h.genresRepo.Create(c, genre)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
@@ -118,9 +141,12 @@ func (h *GenreHandler) Create(c *gin.Context) {
 // @Produce      json
 // @Param        id      path      int                true  "Genre ID"
 // @Param        request body      updateGenreRequest true  "Genre payload"
+// @Param        If-Match  header  string  false  "ETag of the genre as read; the request fails with 412 when it changed since"
 // @Success      200  "OK"
 // @Failure      400  {object}  models.ApiError "Invalid payload"
 // @Failure      404  {object}  models.ApiError "Genre not found"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      412  {object}  models.ApiError "The genre has been modified since it was read"
 // @Failure      500  {object}  models.ApiError
 // @Router       /genres/{id} [put]
 // @Security Bearer
@@ -129,41 +155,55 @@ func (h *GenreHandler) Update(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid Genre Id"))
+		c.Error(apperrors.Validation("Invalid Genre Id"))
 		return
 	}
 
-	_, err = h.genresRepo.FindById(c, id)
+	existing, err := h.genresRepo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
 	var request updateGenreRequest
-	err = c.BindJSON(&request)
+	err = bindJSON(c, &request)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Could not update genre"))
+		c.Error(err)
 		return
 	}
 
 	genre := models.Genre{
-		Title: request.Title,
+		Title:   request.Title,
+		Version: ifMatchVersion(c, existing.Version),
 	}
 
-	h.genresRepo.Update(c, id, genre)
+	version, err := h.genresRepo.Update(c, id, genre)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
 
 // Delete   	 godoc
 // @Summary      Delete genre
+// @Description  The genre is soft deleted: movies no longer list it, and it can be restored until it is purged after the retention period.
 // @Tags         genres
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Genre ID"
+// @Param        If-Match  header  string  false  "ETag of the genre as read; the request fails with 412 when it changed since"
 // @Success      200  "OK"
 // @Failure      400  {object}  models.ApiError "Invalid Genre Id"
 // @Failure      404  {object}  models.ApiError "Genre not found"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      412  {object}  models.ApiError "The genre has been modified since it was read"
 // @Failure      500  {object}  models.ApiError
 // @Router       /genres/{id} [delete]
 // @Security Bearer
@@ -172,21 +212,58 @@ func (h *GenreHandler) Delete(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid Genre Id"))
+		c.Error(apperrors.Validation("Invalid Genre Id"))
+		return
+	}
+
+	existing, err := h.genresRepo.FindById(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
+		return
+	}
+
+	err = h.genresRepo.Delete(c, id, ifMatchVersion(c, existing.Version))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	_, err = h.genresRepo.FindById(c, id)
+	c.Status(http.StatusOK)
+}
+
+// Restore   	 godoc
+// @Summary      Restore deleted genre
+// @Tags         genres
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Genre ID"
+// @Success      200  "OK"
+// @Header       200  {string}  ETag "Version of the restored genre, for If-Match"
+// @Failure      400  {object}  models.ApiError "Invalid Genre Id"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "Genre not found"
+// @Failure      500  {object}  models.ApiError
+// @Router       /genres/{id}/restore [post]
+// @Security Bearer
+func (h *GenreHandler) Restore(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(apperrors.Validation("Invalid Genre Id"))
 		return
 	}
 
-	err = h.genresRepo.Delete(c, id)
+	version, err := h.genresRepo.Restore(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"filmservice/migrations"
+	"filmservice/storage"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// readinessTimeout bounds every check, so that a hanging dependency fails the probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+type HealthHandlers struct {
+	db       *pgxpool.Pool
+	migrator *migrations.Migrator
+	store    storage.BlobStore
+
+	shuttingDown atomic.Bool
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	// Checks maps every readiness check to "ok" or the reason it failed.
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewHealthHandlers(db *pgxpool.Pool, migrator *migrations.Migrator, store storage.BlobStore) *HealthHandlers {
+	return &HealthHandlers{
+		db:       db,
+		migrator: migrator,
+		store:    store,
+	}
+}
+
+// ShutDown makes the instance report itself not ready, so that no new traffic is routed to it while it drains.
+func (h *HealthHandlers) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz   	 godoc
+// @Summary      Liveness probe
+// @Description  Answers as long as the process serves requests; dependencies are not checked.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  handlers.healthResponse "OK"
+// @Router       /healthz [get]
+func (h *HealthHandlers) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readyz   	 godoc
+// @Summary      Readiness probe
+// @Description  Checks that the database answers, every migration is applied and poster storage is writable.
+// @Description  Fails while the instance shuts down.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  handlers.healthResponse "Ready"
+// @Failure      503  {object}  handlers.healthResponse "Not ready, checks name the failing dependency"
+// @Router       /readyz [get]
+func (h *HealthHandlers) Readyz(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, healthResponse{Status: "shutting down"})
+		return
+	}
+
+	checks := map[string]func(c context.Context) error{
+		"database":   h.checkDatabase,
+		"migrations": h.checkMigrations,
+		"storage":    h.checkStorage,
+	}
+
+	response := healthResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+	status := http.StatusOK
+
+	for name, check := range checks {
+		ctx, cancel := context.WithTimeout(c, readinessTimeout)
+		err := check(ctx)
+		cancel()
+
+		if err != nil {
+			response.Checks[name] = err.Error()
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+
+		response.Checks[name] = "ok"
+	}
+
+	c.JSON(status, response)
+}
+
+func (h *HealthHandlers) checkDatabase(c context.Context) error {
+	return h.db.Ping(c)
+}
+
+func (h *HealthHandlers) checkMigrations(c context.Context) error {
+	pending, err := h.migrator.Pending(c)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(pending))
+	for _, migration := range pending {
+		names = append(names, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+	}
+
+	return fmt.Errorf("pending migrations: %s", strings.Join(names, ", "))
+}
+
+// checkStorage writes and deletes a probe object. Every probe uses its own key, so replicas sharing
+// the store do not interfere, and the poster sweeper removes probes a failed delete leaves behind.
+func (h *HealthHandlers) checkStorage(c context.Context) error {
+	key := fmt.Sprintf("readyz-%s", uuid.NewString())
+
+	err := h.store.Put(c, key, strings.NewReader("ok"), 2, "text/plain")
+	if err != nil {
+		return err
+	}
+
+	return h.store.Delete(c, key)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"filmservice/apperrors"
+	"io"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(method string, target string, body io.Reader) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, body)
+
+	return c, w
+}
+
+// invalidField returns the field a validation error names, or "" for other errors.
+func invalidField(err error) string {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation || len(appErr.Fields) == 0 {
+		return ""
+	}
+
+	return appErr.Fields[0].Field
+}
+
+func intPtr(i int) *int           { return &i }
+func floatPtr(f float64) *float64 { return &f }
+func boolPtr(b bool) *bool        { return &b }
+func stringPtr(s string) *string  { return &s }
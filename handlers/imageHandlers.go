@@ -1,34 +1,122 @@
 package handlers
 
 import (
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/config"
+	"filmservice/posters"
+	"filmservice/storage"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-type ImageHandler struct{}
+// imageMaxAge is one year, the longest lifetime caches are expected to honour.
+const imageMaxAge = 365 * 24 * 60 * 60
 
-func NewImageHandler() *ImageHandler {
-	return &ImageHandler{}
+type ImageHandler struct {
+	store storage.BlobStore
 }
 
+func NewImageHandler(store storage.BlobStore) *ImageHandler {
+	return &ImageHandler{store: store}
+}
+
+// HandleGetImageById   	 godoc
+// @Summary      Get poster image
+// @Tags         images
+// @Produce      image/jpeg,image/png
+// @Param        imageId  path      string  true   "Image id, as stored in the movie's PosterUrl"
+// @Param        w        query     int     false  "Desired width; the smallest thumbnail at least that wide is served"
+// @Param        If-None-Match  header  string  false  "ETag of a cached copy"
+// @Param        Range          header  string  false  "Byte range, e.g. bytes=0-1023"
+// @Success      200  {file}    file "Image"
+// @Success      206  {file}    file "Requested byte range"
+// @Success      304  "Cached copy is still valid"
+// @Success      307  "Redirect to a presigned storage URL"
+// @Failure      400  {object}  models.ApiError "Invalid image id or width"
+// @Failure      404  {object}  models.ApiError "Image not found"
+// @Failure      500  {object}  models.ApiError
+// @Router       /images/{imageId} [get]
 func (h *ImageHandler) HandleGetImageById(c *gin.Context) {
 	imageId := c.Param("imageId")
 	if imageId == "" {
-		c.JSON(http.StatusBadRequest, "Invalid image id")
+		c.Error(apperrors.Validation("Invalid image id"))
 		return
 	}
 
-	fileName := filepath.Base(imageId)
-	byteFile, err := os.ReadFile(fmt.Sprintf("images/%s", imageId))
+	key := imageId
+	if widthStr := c.Query("w"); widthStr != "" {
+		width, err := strconv.Atoi(widthStr)
+		if err != nil || width < 1 {
+			c.Error(apperrors.Validation("w must be a positive integer"))
+			return
+		}
+
+		// Posters smaller than the requested width, and those uploaded before thumbnails existed,
+		// only have the original.
+		if variantWidth := posters.VariantWidth(width); variantWidth != 0 {
+			variant := posters.VariantKey(imageId, variantWidth)
+
+			_, err = h.store.Stat(c, variant)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrInvalidKey) {
+				c.Error(err)
+				return
+			}
+			if err == nil {
+				key = variant
+			}
+		}
+	}
+
+	// Stores that can presign let clients download straight from them instead of through the API.
+	if config.Config.StorageRedirect {
+		url, err := h.store.PresignedURL(c, key, config.Config.StoragePresignExpiry)
+		if err == nil {
+			c.Redirect(http.StatusTemporaryRedirect, url)
+			return
+		}
+		if !errors.Is(err, storage.ErrPresignUnsupported) {
+			c.Error(err)
+			return
+		}
+	}
+
+	image, info, err := h.store.Get(c, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.Error(apperrors.NotFound("Image not found"))
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidKey) {
+		c.Error(apperrors.Validation("Invalid image id"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, err.Error())
+		c.Error(err)
 		return
 	}
+	defer image.Close()
+
+	// Files stored before uploads were validated may be anything, so only images are displayed inline.
+	contentType, disposition := info.ContentType, "inline"
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType, disposition = "application/octet-stream", "attachment"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, path.Base(key)))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	// Image ids are random and never reused for other content, so responses can be cached for good.
+	// ServeContent answers conditional and range requests based on these headers.
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", imageMaxAge))
+	if info.ETag != "" {
+		c.Header("ETag", fmt.Sprintf("%q", info.ETag))
+	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
-	c.Data(http.StatusOK, "application/octet-stream", byteFile)
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime, image)
 }
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRating = 5
+
+// parseMovieFilters reads the filter and sort query parameters of the movie list.
+func parseMovieFilters(c *gin.Context) (models.MovieFilters, error) {
+	filters := models.MovieFilters{
+		SearchTerm: strings.TrimSpace(c.Query("search")),
+		Director:   strings.TrimSpace(c.Query("director")),
+		GenreMode:  models.GenreModeAny,
+	}
+
+	genreIds, err := parseIdList(queryValues(c, "genreIds", "genreids"))
+	if err != nil {
+		return models.MovieFilters{}, apperrors.InvalidField("genreIds", err.Error())
+	}
+	filters.GenreIds = genreIds
+
+	if mode := c.Query("genreMode"); mode != "" {
+		if mode != models.GenreModeAny && mode != models.GenreModeAll {
+			return models.MovieFilters{}, apperrors.InvalidField("genreMode", "must be any or all")
+		}
+
+		filters.GenreMode = mode
+	}
+
+	if isWatchedStr := firstQuery(c, "isWatched", "iswatched"); isWatchedStr != "" {
+		isWatched, err := strconv.ParseBool(isWatchedStr)
+		if err != nil {
+			return models.MovieFilters{}, apperrors.InvalidField("isWatched", "must be true or false")
+		}
+
+		filters.IsWatched = &isWatched
+	}
+
+	filters.YearFrom, err = parseOptionalInt(c.Query("yearFrom"))
+	if err != nil {
+		return models.MovieFilters{}, apperrors.InvalidField("yearFrom", "must be an integer")
+	}
+
+	filters.YearTo, err = parseOptionalInt(c.Query("yearTo"))
+	if err != nil {
+		return models.MovieFilters{}, apperrors.InvalidField("yearTo", "must be an integer")
+	}
+
+	if filters.YearFrom != nil && filters.YearTo != nil && *filters.YearFrom > *filters.YearTo {
+		return models.MovieFilters{}, apperrors.InvalidField("yearFrom", "must not be greater than yearTo")
+	}
+
+	filters.MinRating, err = parseRating(c.Query("minRating"))
+	if err != nil {
+		return models.MovieFilters{}, apperrors.InvalidField("minRating", err.Error())
+	}
+
+	filters.MaxRating, err = parseRating(c.Query("maxRating"))
+	if err != nil {
+		return models.MovieFilters{}, apperrors.InvalidField("maxRating", err.Error())
+	}
+
+	if filters.MinRating != nil && filters.MaxRating != nil && *filters.MinRating > *filters.MaxRating {
+		return models.MovieFilters{}, apperrors.InvalidField("minRating", "must not be greater than maxRating")
+	}
+
+	filters.Sort, err = parseSort(c.Query("sort"))
+	if err != nil {
+		return models.MovieFilters{}, err
+	}
+
+	filters.IncludeDeleted, err = parseIncludeDeleted(c)
+	if err != nil {
+		return models.MovieFilters{}, err
+	}
+
+	return filters, nil
+}
+
+// parseSort reads a comma separated list of fields, each optionally suffixed with :asc or :desc,
+// e.g. "release_year:desc,title".
+func parseSort(sort string) ([]models.SortField, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	fields := make([]models.SortField, 0)
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sort, ",") {
+		name, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if name == "" {
+			return nil, apperrors.InvalidField("sort", "contains an empty field")
+		}
+		if seen[name] {
+			return nil, apperrors.InvalidField("sort", fmt.Sprintf("field %q is repeated", name))
+		}
+		seen[name] = true
+
+		field := models.SortField{Field: name}
+		switch strings.ToLower(direction) {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, apperrors.InvalidField("sort", fmt.Sprintf("direction of %q must be asc or desc", name))
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// queryValues collects every value of the first query parameter present, splitting comma separated lists.
+func queryValues(c *gin.Context, keys ...string) []string {
+	values := make([]string, 0)
+
+	for _, key := range keys {
+		for _, value := range c.QueryArray(key) {
+			for _, part := range strings.Split(value, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					values = append(values, part)
+				}
+			}
+		}
+
+		if len(values) > 0 {
+			break
+		}
+	}
+
+	return values
+}
+
+func firstQuery(c *gin.Context, keys ...string) string {
+	for _, key := range keys {
+		if value := c.Query(key); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func parseIdList(values []string) ([]int, error) {
+	ids := make([]int, 0, len(values))
+
+	for _, value := range values {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return nil, errors.New("must be a list of positive integers")
+		}
+
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func parseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &number, nil
+}
+
+func parseRating(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	rating, err := strconv.ParseFloat(value, 64)
+	if err != nil || rating < 0 || rating > maxRating {
+		return nil, fmt.Errorf("must be a number between 0 and %d", maxRating)
+	}
+
+	return &rating, nil
+}
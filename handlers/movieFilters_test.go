@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"filmservice/models"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseMovieFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  models.MovieFilters
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  models.MovieFilters{GenreMode: models.GenreModeAny, GenreIds: []int{}},
+		},
+		{
+			name:  "every filter",
+			query: "search=+alien+&director=Scott&genreIds=3,1&genreIds=3&genreMode=all&isWatched=true&yearFrom=1979&yearTo=1986&minRating=2.5&maxRating=5&sort=rating:desc,title",
+			want: models.MovieFilters{
+				SearchTerm: "alien",
+				Director:   "Scott",
+				GenreIds:   []int{3, 1},
+				GenreMode:  models.GenreModeAll,
+				IsWatched:  boolPtr(true),
+				YearFrom:   intPtr(1979),
+				YearTo:     intPtr(1986),
+				MinRating:  floatPtr(2.5),
+				MaxRating:  floatPtr(5),
+				Sort:       []models.SortField{{Field: "rating", Desc: true}, {Field: "title"}},
+			},
+		},
+		{
+			name:  "lower case aliases",
+			query: "genreids=2&iswatched=false",
+			want:  models.MovieFilters{GenreMode: models.GenreModeAny, GenreIds: []int{2}, IsWatched: boolPtr(false)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testContext(http.MethodGet, "/movies?"+tt.query, nil)
+
+			filters, err := parseMovieFilters(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(filters, tt.want) {
+				t.Errorf("got %+v, want %+v", filters, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMovieFiltersRejects(t *testing.T) {
+	tests := []struct {
+		query string
+		field string
+	}{
+		{query: "genreIds=1,x", field: "genreIds"},
+		{query: "genreIds=0", field: "genreIds"},
+		{query: "genreMode=some", field: "genreMode"},
+		{query: "isWatched=maybe", field: "isWatched"},
+		{query: "yearFrom=old", field: "yearFrom"},
+		{query: "yearTo=new", field: "yearTo"},
+		{query: "yearFrom=2000&yearTo=1990", field: "yearFrom"},
+		{query: "minRating=-1", field: "minRating"},
+		{query: "maxRating=6", field: "maxRating"},
+		{query: "minRating=4&maxRating=3", field: "minRating"},
+		{query: "sort=title:up", field: "sort"},
+		{query: "sort=title,title", field: "sort"},
+		{query: "sort=title,,id", field: "sort"},
+		{query: "includeDeleted=yes", field: "includeDeleted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := testContext(http.MethodGet, "/movies?"+tt.query, nil)
+
+			_, err := parseMovieFilters(c)
+			if field := invalidField(err); field != tt.field {
+				t.Errorf("got %v, want a validation error of %s", err, tt.field)
+			}
+		})
+	}
+}
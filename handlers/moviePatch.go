@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"mime"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errUnsupportedMediaType = apperrors.UnsupportedMediaType("Content type must be application/merge-patch+json, application/json or multipart/form-data")
+
+// moviePatchRequest is a parsed PATCH body. Genres are resolved and the poster is stored by the handler.
+type moviePatchRequest struct {
+	patch    models.MoviePatch
+	genreIds *[]int
+	poster   *multipart.FileHeader
+}
+
+func parseMoviePatch(c *gin.Context) (moviePatchRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+
+	var request moviePatchRequest
+	var err error
+
+	switch mediaType {
+	case "application/merge-patch+json", "application/json":
+		request, err = parseJsonMoviePatch(c)
+	case "multipart/form-data":
+		request, err = parseMultipartMoviePatch(c)
+	default:
+		return moviePatchRequest{}, errUnsupportedMediaType
+	}
+	if err != nil {
+		return moviePatchRequest{}, err
+	}
+
+	return request, validate(request.patch)
+}
+
+// parseJsonMoviePatch reads a JSON Merge Patch (RFC 7396). Null clears optional text fields and the poster;
+// a new poster can only be uploaded with multipart.
+func parseJsonMoviePatch(c *gin.Context) (moviePatchRequest, error) {
+	var fields map[string]json.RawMessage
+
+	err := json.NewDecoder(c.Request.Body).Decode(&fields)
+	if err != nil {
+		return moviePatchRequest{}, apperrors.Validation("Body must be a JSON object")
+	}
+
+	var request moviePatchRequest
+	empty := ""
+
+	for name, raw := range fields {
+		isNull := string(raw) == "null"
+
+		switch name {
+		case "title":
+			request.patch.Title, err = decodePatchString(name, raw, false)
+		case "description":
+			request.patch.Description, err = decodePatchString(name, raw, true)
+		case "director":
+			request.patch.Director, err = decodePatchString(name, raw, true)
+		case "trailerUrl":
+			request.patch.TrailerUrl, err = decodePatchString(name, raw, true)
+		case "releaseYear":
+			var year int
+			if isNull || json.Unmarshal(raw, &year) != nil {
+				err = apperrors.InvalidField("releaseYear", "must be an integer")
+			}
+			request.patch.ReleaseYear = &year
+		case "genreIds":
+			var ids []int
+			if isNull || json.Unmarshal(raw, &ids) != nil {
+				err = apperrors.InvalidField("genreIds", "must be a list of positive integers")
+				break
+			}
+			request.genreIds, err = uniqueGenreIds(ids)
+		case "poster":
+			if !isNull {
+				err = apperrors.InvalidField("poster", "can only be removed with null; upload a new one with multipart/form-data")
+			}
+			request.patch.PosterUrl = &empty
+		default:
+			err = apperrors.InvalidField(name, "is not a known field")
+		}
+
+		if err != nil {
+			return moviePatchRequest{}, err
+		}
+	}
+
+	return request, nil
+}
+
+// parseMultipartMoviePatch changes only the fields present in the form.
+func parseMultipartMoviePatch(c *gin.Context) (moviePatchRequest, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return moviePatchRequest{}, apperrors.Validation("Could not read multipart form")
+	}
+
+	var request moviePatchRequest
+
+	for name, values := range form.Value {
+		value := strings.TrimSpace(values[0])
+
+		switch name {
+		case "title":
+			if value == "" {
+				return moviePatchRequest{}, apperrors.InvalidField("title", "must not be empty")
+			}
+			request.patch.Title = &value
+		case "description":
+			request.patch.Description = &value
+		case "director":
+			request.patch.Director = &value
+		case "trailerUrl":
+			request.patch.TrailerUrl = &value
+		case "releaseYear":
+			year, err := strconv.Atoi(value)
+			if err != nil {
+				return moviePatchRequest{}, apperrors.InvalidField("releaseYear", "must be an integer")
+			}
+			request.patch.ReleaseYear = &year
+		case "genreIds":
+			parts := make([]string, 0, len(values))
+			for _, value := range values {
+				parts = append(parts, strings.Split(value, ",")...)
+			}
+
+			ids, err := parseIdList(parts)
+			if err != nil {
+				return moviePatchRequest{}, apperrors.InvalidField("genreIds", err.Error())
+			}
+			request.genreIds = &ids
+		default:
+			return moviePatchRequest{}, apperrors.InvalidField(name, "is not a known field")
+		}
+	}
+
+	for name, files := range form.File {
+		if name != "poster" {
+			return moviePatchRequest{}, apperrors.InvalidField(name, "is not a known file")
+		}
+
+		request.poster = files[0]
+	}
+
+	return request, nil
+}
+
+func decodePatchString(name string, raw json.RawMessage, nullable bool) (*string, error) {
+	value := ""
+
+	if string(raw) != "null" {
+		err := json.Unmarshal(raw, &value)
+		if err != nil {
+			return nil, apperrors.InvalidField(name, "must be a string")
+		}
+		value = strings.TrimSpace(value)
+	}
+
+	if value == "" && !nullable {
+		return nil, apperrors.InvalidField(name, "must not be empty")
+	}
+
+	return &value, nil
+}
+
+func uniqueGenreIds(ids []int) (*[]int, error) {
+	unique := make([]int, 0, len(ids))
+
+	for _, id := range ids {
+		if id < 1 {
+			return nil, apperrors.InvalidField("genreIds", "must be a list of positive integers")
+		}
+
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	return &unique, nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func patchContext(contentType string, body string) *gin.Context {
+	c, _ := testContext(http.MethodPatch, "/movies/1", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+
+	return c
+}
+
+func TestParseJsonMoviePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		patch    models.MoviePatch
+		genreIds *[]int
+	}{
+		{
+			name:  "absent fields stay unchanged",
+			body:  `{}`,
+			patch: models.MoviePatch{},
+		},
+		{
+			name: "set fields",
+			body: `{"title": " Alien ", "releaseYear": 1979, "director": "Ridley Scott", "trailerUrl": "https://example.com/t"}`,
+			patch: models.MoviePatch{
+				Title:       stringPtr("Alien"),
+				ReleaseYear: intPtr(1979),
+				Director:    stringPtr("Ridley Scott"),
+				TrailerUrl:  stringPtr("https://example.com/t"),
+			},
+		},
+		{
+			name: "null clears optional fields and the poster",
+			body: `{"description": null, "director": null, "trailerUrl": null, "poster": null}`,
+			patch: models.MoviePatch{
+				Description: stringPtr(""),
+				Director:    stringPtr(""),
+				TrailerUrl:  stringPtr(""),
+				PosterUrl:   stringPtr(""),
+			},
+		},
+		{
+			name:     "genre ids are deduplicated",
+			body:     `{"genreIds": [2, 1, 2]}`,
+			genreIds: &[]int{2, 1},
+		},
+		{
+			name:     "empty genre list removes every genre",
+			body:     `{"genreIds": []}`,
+			genreIds: &[]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := parseMoviePatch(patchContext("application/merge-patch+json", tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(request.patch, tt.patch) {
+				t.Errorf("patch %+v, want %+v", request.patch, tt.patch)
+			}
+			if !reflect.DeepEqual(request.genreIds, tt.genreIds) {
+				t.Errorf("genre ids %v, want %v", request.genreIds, tt.genreIds)
+			}
+		})
+	}
+}
+
+func TestParseJsonMoviePatchRejects(t *testing.T) {
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{body: `{"title": null}`, field: "title"},
+		{body: `{"title": "  "}`, field: "title"},
+		{body: `{"title": 5}`, field: "title"},
+		{body: `{"releaseYear": null}`, field: "releaseYear"},
+		{body: `{"releaseYear": "1979"}`, field: "releaseYear"},
+		{body: `{"releaseYear": 1700}`, field: "releaseYear"},
+		{body: `{"genreIds": [0]}`, field: "genreIds"},
+		{body: `{"genreIds": null}`, field: "genreIds"},
+		{body: `{"poster": "x.jpg"}`, field: "poster"},
+		{body: `{"rating": 5}`, field: "rating"},
+		{body: `{"trailerUrl": "not a url"}`, field: "trailerUrl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			_, err := parseMoviePatch(patchContext("application/json", tt.body))
+			if field := invalidField(err); field != tt.field {
+				t.Errorf("got %v, want a validation error of %s", err, tt.field)
+			}
+		})
+	}
+
+	t.Run("not an object", func(t *testing.T) {
+		_, err := parseMoviePatch(patchContext("application/json", `[1]`))
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+			t.Errorf("got %v, want a validation error", err)
+		}
+	})
+}
+
+func TestParseMoviePatchRejectsMediaType(t *testing.T) {
+	_, err := parseMoviePatch(patchContext("text/plain", `title=Alien`))
+	if !errors.Is(err, errUnsupportedMediaType) {
+		t.Errorf("got %v, want errUnsupportedMediaType", err)
+	}
+}
+
+func TestParseMultipartMoviePatch(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("title", "Alien")
+	form.WriteField("description", "")
+	form.WriteField("genreIds", "3,1")
+	form.WriteField("genreIds", "3")
+	poster, _ := form.CreateFormFile("poster", "poster.png")
+	poster.Write([]byte("png"))
+	form.Close()
+
+	request, err := parseMoviePatch(patchContext(form.FormDataContentType(), body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.MoviePatch{Title: stringPtr("Alien"), Description: stringPtr("")}
+	if !reflect.DeepEqual(request.patch, want) {
+		t.Errorf("patch %+v, want %+v", request.patch, want)
+	}
+	if request.genreIds == nil || !reflect.DeepEqual(*request.genreIds, []int{3, 1}) {
+		t.Errorf("genre ids %v, want [3 1]", request.genreIds)
+	}
+	if request.poster == nil || request.poster.Filename != "poster.png" {
+		t.Errorf("poster %v was not picked up", request.poster)
+	}
+}
@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"bytes"
+	"filmservice/apperrors"
+	"filmservice/config"
 	logger2 "filmservice/logger"
+	"filmservice/metrics"
 	"filmservice/models"
+	"filmservice/posters"
 	"filmservice/repositories"
+	"filmservice/storage"
 	"fmt"
 	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,37 +23,40 @@ import (
 )
 
 type MoviesHandler struct {
-	moviesRepo *repositories.MoviesRepository
-	genresRepo *repositories.GenresRepository
+	moviesRepo  *repositories.MoviesRepository
+	genresRepo  *repositories.GenresRepository
+	posterStore storage.BlobStore
 }
 
 type createMovieRequest struct {
-	Title       string                `form:"title"`
-	Description string                `form:"description"`
-	ReleaseYear int                   `form:"releaseYear"`
-	Director    string                `form:"director"`
-	TrailerUrl  string                `form:"trailerUrl"`
-	GenreIds    []int                 `form:"genreIds"`
-	Poster      *multipart.FileHeader `form:"poster"`
+	Title       string                `form:"title" binding:"required,max=255"`
+	Description string                `form:"description" binding:"max=5000"`
+	ReleaseYear int                   `form:"releaseYear" binding:"required,min=1888,max=2100"`
+	Director    string                `form:"director" binding:"max=255"`
+	TrailerUrl  string                `form:"trailerUrl" binding:"omitempty,url,max=2048"`
+	GenreIds    []int                 `form:"genreIds" binding:"required,min=1,dive,gt=0"`
+	Poster      *multipart.FileHeader `form:"poster" binding:"required"`
 }
 
 type updateMovieRequest struct {
-	Title       string                `form:"title"`
-	Description string                `form:"description"`
-	ReleaseYear int                   `form:"releaseYear"`
-	Director    string                `form:"director"`
-	TrailerUrl  string                `form:"trailerUrl"`
-	GenreIds    []int                 `form:"genreIds"`
+	Title       string                `form:"title" binding:"required,max=255"`
+	Description string                `form:"description" binding:"max=5000"`
+	ReleaseYear int                   `form:"releaseYear" binding:"required,min=1888,max=2100"`
+	Director    string                `form:"director" binding:"max=255"`
+	TrailerUrl  string                `form:"trailerUrl" binding:"omitempty,url,max=2048"`
+	GenreIds    []int                 `form:"genreIds" binding:"required,min=1,dive,gt=0"`
 	Poster      *multipart.FileHeader `form:"poster"`
 }
 
 func NewMoviesHandler(
 	moviesRepo *repositories.MoviesRepository,
 	genreRepo *repositories.GenresRepository,
+	posterStore storage.BlobStore,
 ) *MoviesHandler {
 	return &MoviesHandler{
-		moviesRepo: moviesRepo,
-		genresRepo: genreRepo,
+		moviesRepo:  moviesRepo,
+		genresRepo:  genreRepo,
+		posterStore: posterStore,
 	}
 }
 
@@ -55,25 +65,44 @@ func NewMoviesHandler(
 // @Tags         movies
 // @Accept       json
 // @Produce      json
-// @Param        filters query models.MovieFilters true "Movie filters"
-// @Success      200  {object}  models.Movie "OK"
+// @Param        search     query     string  false  "Full-text search over title, director and description; tolerates typos in titles"
+// @Param        director   query     string  false  "Director contains"
+// @Param        genreIds   query     []int   false  "Genre ids, repeated or comma separated" collectionFormat(csv)
+// @Param        genreMode  query     string  false  "Match any or all of the genre ids" Enums(any, all)
+// @Param        isWatched  query     bool    false  "Watched by the caller"
+// @Param        yearFrom   query     int     false  "Earliest release year"
+// @Param        yearTo     query     int     false  "Latest release year"
+// @Param        minRating  query     number  false  "Lowest average rating (0-5)"
+// @Param        maxRating  query     number  false  "Highest average rating (0-5)"
+// @Param        sort       query     string  false  "Comma separated fields with optional :asc or :desc, e.g. release_year:desc,title; relevance is available while searching and is the default then"
+// @Param        includeDeleted  query  bool  false  "List deleted movies too, admins only"
+// @Param        limit   query     int     false  "Page size (1-100, default 20)"
+// @Param        cursor  query     string  false  "nextCursor of the previous page"
+// @Param        offset  query     int     false  "Rows to skip, ignored when a cursor is given"
+// @Success      200  {object}  models.Page[models.Movie] "OK"
+// @Failure      400  {object}  models.ApiError "Invalid filter, sort or page parameters"
+// @Failure      403  {object}  models.ApiError "Admin role required for includeDeleted"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies [get]
 // @Security Bearer
 func (h *MoviesHandler) FindAll(c *gin.Context) {
-	filters := models.MovieFilters{
-		SearchTerm: c.Query("search"),
-		GenreId:    c.Query("genreids"),
-		IsWatched:  c.Query("iswatched"),
-		Sort:       c.Query("sort"),
+	filters, err := parseMovieFilters(c)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	movies, err := h.moviesRepo.FindAll(c, filters)
+	page, err := parsePageRequest(c)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			err,
-		)
+		c.Error(err)
+		return
+	}
+
+	userId := c.GetInt("userId")
+
+	movies, err := h.moviesRepo.FindAll(c, userId, filters, page)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -90,6 +119,7 @@ func (h *MoviesHandler) FindAll(c *gin.Context) {
 // @Produce      json
 // @Param        id   path      int  true  "Movie ID"
 // @Success      200  {object}  models.Movie "OK"
+// @Header       200  {string}  ETag "Version of the movie, for If-Match"
 // @Failure      400  {object}  models.ApiError "Invalid movie id"
 // @Failure      404  {object}  models.ApiError "Movie not found"
 // @Failure      500  {object}  models.ApiError
@@ -100,25 +130,23 @@ func (h *MoviesHandler) FindById(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid Movie Id"),
-		)
+		c.Error(apperrors.Validation("Invalid Movie Id"))
 		return
 	}
 
+	userId := c.GetInt("userId")
+
 	movie, err := h.moviesRepo.FindById(
 		c,
 		id,
+		userId,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
 		return
 	}
 
+	setETag(c, movie.Version)
 	c.JSON(
 		http.StatusOK,
 		movie,
@@ -136,33 +164,28 @@ func (h *MoviesHandler) FindById(c *gin.Context) {
 // @Param        director formData string true "Director"
 // @Param        trailerUrl formData string true "Trailer URL"
 // @Param        genreIds formData []int true "Genre ids"
-// @Param        poster formData file true "Poster image"
+// @Param        poster formData file true "Poster image: JPEG, PNG or WebP"
 // @Success      200  {object}  object{id=int} "OK"
 // @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      403  {object}  models.ApiError "Admin role required"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies [post]
 // @Security Bearer
 func (h *MoviesHandler) Create(c *gin.Context) {
 	var request createMovieRequest
 
-	err := c.Bind(&request)
+	err := bind(c, &request)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Could not bind payload"),
-		)
+		c.Error(err)
 		return
 	}
 
-	genres, err := h.genresRepo.FindAllByIds(
+	genres, err := h.findGenres(
 		c,
 		request.GenreIds,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
 		return
 	}
 
@@ -171,10 +194,7 @@ func (h *MoviesHandler) Create(c *gin.Context) {
 		request.Poster,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
 		return
 	}
 
@@ -193,15 +213,13 @@ func (h *MoviesHandler) Create(c *gin.Context) {
 		movie,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			models.NewApiError(err.Error()),
-		)
+		h.deletePoster(c, filename)
+		c.Error(err)
 		return
 	}
 
-	logger := logger2.GetLogger()
-	logger.Info("movie has been created", zap.Int("movie_id", id))
+	metrics.MoviesCreated.Inc()
+	logger2.FromContext(c).Info("movie has been created", zap.Int("movie_id", id))
 
 	c.JSON(
 		http.StatusOK,
@@ -212,15 +230,75 @@ func (h *MoviesHandler) Create(c *gin.Context) {
 
 }
 
+// saveMoviePoster validates and re-encodes the uploaded poster and stores it with its thumbnails.
+// It returns the key of the original, which is what movies reference.
 func (h *MoviesHandler) saveMoviePoster(
 	c *gin.Context, poster *multipart.FileHeader) (string, error) {
-	filename := fmt.Sprintf("%s%s", uuid.NewString(), filepath.Ext(poster.Filename))
+	if poster.Size > config.Config.PosterMaxBytes {
+		return "", posters.ErrTooLarge
+	}
+
+	file, err := poster.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	processed, err := posters.Process(file, config.Config.PosterMaxBytes)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("%s%s", uuid.NewString(), processed.Ext)
+
+	images := map[string]posters.Image{filename: processed.Original}
+	for _, thumbnail := range processed.Thumbnails {
+		images[posters.VariantKey(filename, thumbnail.Width)] = thumbnail
+	}
+
+	for key, image := range images {
+		err = h.posterStore.Put(c, key, bytes.NewReader(image.Content), int64(len(image.Content)), image.ContentType)
+		if err != nil {
+			return "", err
+		}
+		metrics.PosterBytesStored.Add(float64(len(image.Content)))
+	}
+
+	return filename, nil
+}
+
+// findGenres loads the genres with the given ids and reports the ids no genre has.
+func (h *MoviesHandler) findGenres(c *gin.Context, ids []int) ([]models.Genre, error) {
+	genres, err := h.genresRepo.FindAllByIds(c, ids)
+	if err != nil {
+		return nil, err
+	}
 
-	filepathF := fmt.Sprintf("images/%s", filename)
+	unknown := make([]string, 0)
+	for _, id := range ids {
+		if !slices.ContainsFunc(genres, func(genre models.Genre) bool { return genre.Id == id }) {
+			unknown = append(unknown, strconv.Itoa(id))
+		}
+	}
 
-	err := c.SaveUploadedFile(poster, filepathF)
+	if len(unknown) > 0 {
+		return nil, apperrors.InvalidField("genreIds", "contains unknown genres "+strings.Join(unknown, ", "))
+	}
 
-	return filename, err
+	return genres, nil
+}
+
+// deletePoster removes a poster that is no longer referenced. Failures are only logged,
+// the garbage collector removes whatever is left behind.
+func (h *MoviesHandler) deletePoster(c *gin.Context, key string) {
+	if key == "" {
+		return
+	}
+
+	err := posters.Delete(c, h.posterStore, key)
+	if err != nil {
+		logger2.FromContext(c).Warn("could not delete poster", zap.String("poster", key), zap.Error(err))
+	}
 }
 
 // Update   	 godoc
@@ -235,9 +313,13 @@ func (h *MoviesHandler) saveMoviePoster(
 // @Param        director formData string true "Director"
 // @Param        trailerUrl formData string true "Trailer URL"
 // @Param        genreIds formData []int true "Genre ids"
-// @Param        poster formData file true "Poster image"
+// @Param        poster formData file false "Poster image: JPEG, PNG or WebP; the current one is kept when omitted"
+// @Param        If-Match  header  string  false  "ETag of the movie as read; the request fails with 412 when it changed since"
 // @Success      200  {object}  object{id=int} "OK"
 // @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "Movie not found"
+// @Failure      412  {object}  models.ApiError "The movie has been modified since it was read"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies/{id} [put]
 // @Security Bearer
@@ -246,57 +328,51 @@ func (h *MoviesHandler) Update(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid Movie Id"),
-		)
+		c.Error(apperrors.Validation("Invalid Movie Id"))
 		return
 	}
 
-	_, err = h.moviesRepo.FindById(
+	existing, err := h.moviesRepo.FindById(
 		c,
 		id,
+		c.GetInt("userId"),
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
 	var request updateMovieRequest
-	err = c.Bind(&request)
+	err = bind(c, &request)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Could not update payload"),
-		)
+		c.Error(err)
 		return
 	}
 
-	genres, err := h.genresRepo.FindAllByIds(
+	genres, err := h.findGenres(
 		c,
 		request.GenreIds,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
 		return
 	}
 
-	filename, err := h.saveMoviePoster(
-		c,
-		request.Poster,
-	)
-	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			models.NewApiError(err.Error()),
+	// Without an upload the current poster is kept.
+	filename := ""
+	if request.Poster != nil {
+		filename, err = h.saveMoviePoster(
+			c,
+			request.Poster,
 		)
-		return
+		if err != nil {
+			c.Error(err)
+			return
+		}
 	}
 
 	movie := models.Movie{
@@ -307,32 +383,128 @@ func (h *MoviesHandler) Update(c *gin.Context) {
 		TrailerUrl:  request.TrailerUrl,
 		PosterUrl:   filename,
 		Genres:      genres,
+		Version:     ifMatchVersion(c, existing.Version),
 	}
 
-	err = h.moviesRepo.Update(
+	previousPoster, version, err := h.moviesRepo.Update(
 		c,
 		id,
 		movie,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			models.NewApiError(err.Error()),
-		)
+		h.deletePoster(c, filename)
+		c.Error(err)
+		return
+	}
+
+	if filename != "" && previousPoster != filename {
+		h.deletePoster(c, previousPoster)
+	}
+
+	setETag(c, version)
+	c.Status(http.StatusOK)
+}
+
+// Patch   	 godoc
+// @Summary      Partially update movie
+// @Description  Accepts a JSON Merge Patch, where null clears description, director, trailerUrl or poster,
+// @Description  or a multipart form with only the fields to change. Omitted fields, genres and the poster are kept.
+// @Tags         movies
+// @Accept       application/merge-patch+json,multipart/form-data
+// @Produce      json
+// @Param        id path int true "Movie id"
+// @Param        title formData string false "Title"
+// @Param        description formData string false "Description"
+// @Param        releaseYear formData int false "ReleaseYear"
+// @Param        director formData string false "Director"
+// @Param        trailerUrl formData string false "Trailer URL"
+// @Param        genreIds formData []int false "Genre ids, replacing the current ones"
+// @Param        poster formData file false "Poster image: JPEG, PNG or WebP"
+// @Param        If-Match  header  string  false  "ETag of the movie as read; the request fails with 412 when it changed since"
+// @Success      200  "OK"
+// @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "Movie not found"
+// @Failure      415  {object}  models.ApiError "Unsupported content type"
+// @Failure      412  {object}  models.ApiError "The movie has been modified since it was read"
+// @Failure      500  {object}  models.ApiError
+// @Router       /movies/{id} [patch]
+// @Security Bearer
+func (h *MoviesHandler) Patch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid Movie Id"))
+		return
+	}
+
+	existing, err := h.moviesRepo.FindById(c, id, c.GetInt("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
+		return
+	}
+
+	request, err := parseMoviePatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	patch := request.patch
+	patch.Version = ifMatchVersion(c, existing.Version)
+
+	if request.genreIds != nil {
+		genres, err := h.findGenres(c, *request.genreIds)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		patch.Genres = &genres
+	}
+
+	filename := ""
+	if request.poster != nil {
+		filename, err = h.saveMoviePoster(c, request.poster)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		patch.PosterUrl = &filename
+	}
+
+	previousPoster, version, err := h.moviesRepo.Patch(c, id, patch)
+	if err != nil {
+		h.deletePoster(c, filename)
+		c.Error(err)
 		return
 	}
 
+	if patch.PosterUrl != nil && previousPoster != *patch.PosterUrl {
+		h.deletePoster(c, previousPoster)
+	}
+
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
 
 // Delete   	 godoc
 // @Summary      Delete movie
+// @Description  The movie is soft deleted: it can be restored until it is purged after the retention period.
 // @Tags         movies
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Movie id"
+// @Param        If-Match  header  string  false  "ETag of the movie as read; the request fails with 412 when it changed since"
 // @Success      200  "OK"
 // @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "Movie not found"
+// @Failure      412  {object}  models.ApiError "The movie has been modified since it was read"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies/{id} [delete]
 // @Security Bearer
@@ -341,39 +513,68 @@ func (h *MoviesHandler) Delete(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid Movie Id"),
-		)
+		c.Error(apperrors.Validation("Invalid Movie Id"))
 		return
 	}
 
-	_, err = h.moviesRepo.FindById(c, id)
+	existing, err := h.moviesRepo.FindById(c, id, c.GetInt("userId"))
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
 	err = h.moviesRepo.Delete(
 		c,
 		id,
+		ifMatchVersion(c, existing.Version),
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError,
-			models.NewApiError(err.Error()),
-		)
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Restore   	 godoc
+// @Summary      Restore deleted movie
+// @Tags         movies
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Movie id"
+// @Success      200  "OK"
+// @Header       200  {string}  ETag "Version of the restored movie, for If-Match"
+// @Failure      400  {object}  models.ApiError "Invalid movie id"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "Movie not found"
+// @Failure      500  {object}  models.ApiError
+// @Router       /movies/{id}/restore [post]
+// @Security Bearer
+func (h *MoviesHandler) Restore(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid Movie Id"))
 		return
 	}
 
+	version, err := h.moviesRepo.Restore(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
 
 // HandleSetRating   	 godoc
-// @Summary      Set movie rating
+// @Summary      Set current user's movie rating
 // @Tags         movies
 // @Accept       json
 // @Produce      json
@@ -381,6 +582,7 @@ func (h *MoviesHandler) Delete(c *gin.Context) {
 // @Param        rating   query   int  true  "Movie rating"
 // @Success      200  "OK"
 // @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      404  {object}  models.ApiError "Movie not found"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies/{id}/rate [patch]
 // @Security Bearer
@@ -388,41 +590,36 @@ func (h *MoviesHandler) HandleSetRating(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid movie Id"),
-		)
+		c.Error(apperrors.Validation("Invalid movie Id"))
 		return
 	}
 
 	ratingStr := c.Query("rating")
 	rating, err := strconv.Atoi(ratingStr)
 	if err != nil || rating < 1 || rating > 5 {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid rating value"),
-		)
+		c.Error(apperrors.Validation("Invalid rating value"))
 		return
 	}
 
+	userId := c.GetInt("userId")
+
 	err = h.moviesRepo.SetRating(
 		c,
 		id,
+		userId,
 		rating,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid rating value"),
-		)
+		c.Error(err)
 		return
 	}
 
+	metrics.RatingsSubmitted.Inc()
 	c.Status(http.StatusOK)
 }
 
 // HandleSetWatched   	 godoc
-// @Summary      Mark movie as watched
+// @Summary      Mark movie as watched by current user
 // @Tags         movies
 // @Accept       json
 // @Produce      json
@@ -430,6 +627,7 @@ func (h *MoviesHandler) HandleSetRating(c *gin.Context) {
 // @Param        isWatched   query   bool  true  "Flag value"
 // @Success      200  "OK"
 // @Failure      400  {object}  models.ApiError "Invalid data"
+// @Failure      404  {object}  models.ApiError "Movie not found"
 // @Failure      500  {object}  models.ApiError
 // @Router       /movies/{id}/setWatched [patch]
 // @Security Bearer
@@ -437,33 +635,27 @@ func (h *MoviesHandler) HandleSetWatched(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid movie Id"),
-		)
+		c.Error(apperrors.Validation("Invalid movie Id"))
 		return
 	}
 
 	isWatchedStr := c.Query("isWatched")
 	isWatched, err := strconv.ParseBool(isWatchedStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid isWatched value"),
-		)
+		c.Error(apperrors.Validation("Invalid isWatched value"))
 		return
 	}
 
+	userId := c.GetInt("userId")
+
 	err = h.moviesRepo.SetWatched(
 		c,
 		id,
+		userId,
 		isWatched,
 	)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid isWatched value"),
-		)
+		c.Error(err)
 		return
 	}
 
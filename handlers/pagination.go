@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"filmservice/apperrors"
+	"filmservice/models"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePageRequest reads the limit, cursor and offset query parameters shared by list endpoints.
+func parsePageRequest(c *gin.Context) (models.PageRequest, error) {
+	page := models.PageRequest{
+		Limit:  defaultPageLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return models.PageRequest{}, apperrors.InvalidField("limit", fmt.Sprintf("must be between 1 and %d", maxPageLimit))
+		}
+
+		page.Limit = limit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return models.PageRequest{}, apperrors.InvalidField("offset", "must be a non-negative integer")
+		}
+
+		page.Offset = offset
+	}
+
+	return page, nil
+}
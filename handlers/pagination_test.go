@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"filmservice/models"
+	"net/http"
+	"testing"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		query string
+		want  models.PageRequest
+		field string
+	}{
+		{query: "", want: models.PageRequest{Limit: defaultPageLimit}},
+		{query: "limit=5&offset=10&cursor=abc", want: models.PageRequest{Limit: 5, Offset: 10, Cursor: "abc"}},
+		{query: "limit=0", field: "limit"},
+		{query: "limit=101", field: "limit"},
+		{query: "offset=-1", field: "offset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := testContext(http.MethodGet, "/movies?"+tt.query, nil)
+
+			page, err := parsePageRequest(c)
+			if tt.field != "" {
+				if field := invalidField(err); field != tt.field {
+					t.Errorf("got %v, want a validation error of %s", err, tt.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page != tt.want {
+				t.Errorf("got %+v, want %+v", page, tt.want)
+			}
+		})
+	}
+}
@@ -1,42 +1,55 @@
 package handlers
 
 import (
+	"filmservice/apperrors"
 	"filmservice/models"
 	"filmservice/repositories"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UsersHandlers struct {
-	repo *repositories.UsersRepository
+	repo       *repositories.UsersRepository
+	tokensRepo *repositories.TokensRepository
 }
 
 type createUserRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,password"`
+	Role     string `json:"role" binding:"omitempty,oneof=admin viewer" enums:"admin,viewer" default:"viewer"`
 }
 
 type updateUserRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name  string `json:"name" binding:"required,max=100"`
+	Email string `json:"email" binding:"required,email,max=254"`
+	Role  string `json:"role,omitempty" binding:"omitempty,oneof=admin viewer" enums:"admin,viewer"`
 }
 
 type changeUserPasswordRequest struct {
-	Password string `json:"password"`
+	Password string `json:"password" binding:"required,password"`
 }
 
 type userResponse struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Id        int        `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
-func NewUsersHandlers(usersRepo *repositories.UsersRepository) *UsersHandlers {
-	return &UsersHandlers{repo: usersRepo}
+func NewUsersHandlers(
+	usersRepo *repositories.UsersRepository,
+	tokensRepo *repositories.TokensRepository,
+) *UsersHandlers {
+	return &UsersHandlers{
+		repo:       usersRepo,
+		tokensRepo: tokensRepo,
+	}
 }
 
 // FindAll   	 godoc
@@ -44,30 +57,54 @@ func NewUsersHandlers(usersRepo *repositories.UsersRepository) *UsersHandlers {
 // @Tags         users
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   userResponse "OK"
+// @Param        limit   query     int     false  "Page size (1-100, default 20)"
+// @Param        cursor  query     string  false  "nextCursor of the previous page"
+// @Param        offset  query     int     false  "Rows to skip, ignored when a cursor is given"
+// @Param        includeDeleted  query  bool  false  "List deleted users too"
+// @Success      200  {object}  models.Page[userResponse] "OK"
+// @Failure      400  {object}  models.ApiError "Invalid page parameters"
+// @Failure      403  {object}  models.ApiError "Admin role required"
 // @Failure      500  {object}  models.ApiError
 // @Router       /users [get]
 // @Security Bearer
 func (h *UsersHandlers) FindAll(c *gin.Context) {
-	users, err := h.repo.FindAll(c)
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	users, err := h.repo.FindAll(c, page, includeDeleted)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("could not load users"))
+		c.Error(err)
 		return
 	}
 
-	dtos := make([]userResponse, 0, len(users))
+	dtos := make([]userResponse, 0, len(users.Items))
 
-	for _, u := range users {
+	for _, u := range users.Items {
 		r := userResponse{
-			Id:    u.Id,
-			Name:  u.Name,
-			Email: u.Email,
+			Id:        u.Id,
+			Name:      u.Name,
+			Email:     u.Email,
+			Role:      u.Role,
+			DeletedAt: u.DeletedAt,
 		}
 
 		dtos = append(dtos, r)
 	}
 
-	c.JSON(http.StatusOK, dtos)
+	c.JSON(http.StatusOK, models.Page[userResponse]{
+		Items:      dtos,
+		NextCursor: users.NextCursor,
+		Total:      users.Total,
+	})
 }
 
 // FindById   	 godoc
@@ -77,7 +114,10 @@ func (h *UsersHandlers) FindAll(c *gin.Context) {
 // @Produce      json
 // @Param        id   path      int  true  "User ID"
 // @Success      200  {object}  userResponse "OK"
+// @Header       200  {string}  ETag "Version of the user, for If-Match"
 // @Failure      400  {object}  models.ApiError "Invalid User Id"
+// @Failure      403  {object}  models.ApiError "Not allowed to access this account"
+// @Failure      404  {object}  models.ApiError "User not found"
 // @Failure      500  {object}  models.ApiError
 // @Router       /users/{id} [get]
 // @Security Bearer
@@ -86,16 +126,18 @@ func (h *UsersHandlers) FindById(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(
-			http.StatusBadRequest,
-			models.NewApiError("Invalid User Id"),
-		)
+		c.Error(apperrors.Validation("Invalid User Id"))
+		return
+	}
+
+	if !canManageUser(c, id) {
+		c.Error(apperrors.Forbidden("You can only access your own account"))
 		return
 	}
 
 	user, err := h.repo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
@@ -103,8 +145,10 @@ func (h *UsersHandlers) FindById(c *gin.Context) {
 		Id:    user.Id,
 		Name:  user.Name,
 		Email: user.Email,
+		Role:  user.Role,
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, r)
 }
 
@@ -116,32 +160,42 @@ func (h *UsersHandlers) FindById(c *gin.Context) {
 // @Param        request  body      createUserRequest  true  "Create user payload"
 // @Success      200      {object}  object{id=int}     "OK"
 // @Failure      400      {object}  models.ApiError   "Invalid payload"
+// @Failure      403      {object}  models.ApiError   "Admin role required"
+// @Failure      409      {object}  models.ApiError   "Email is already taken"
 // @Failure      500      {object}  models.ApiError
 // @Router       /users [post]
 // @Security Bearer
 func (h *UsersHandlers) Create(c *gin.Context) {
 	var request createUserRequest
-	err := c.BindJSON(&request)
+	err := bindJSON(c, &request)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid payload"))
+		c.Error(err)
 		return
 	}
 
+	if request.Role == "" {
+		request.Role = models.RoleViewer
+	}
+
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("Failed hash password"))
+		c.Error(err)
 		return
 	}
 
+	// Accounts created by an administrator do not need to go through email verification.
+	verifiedAt := time.Now()
 	user := models.User{
-		Name:         request.Name,
-		Email:        request.Email,
-		PasswordHash: string(passwordHash),
+		Name:            request.Name,
+		Email:           request.Email,
+		PasswordHash:    string(passwordHash),
+		Role:            request.Role,
+		EmailVerifiedAt: &verifiedAt,
 	}
 
 	id, err := h.repo.Create(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("Could not create user"))
+		c.Error(err)
 		return
 	}
 
@@ -150,13 +204,19 @@ func (h *UsersHandlers) Create(c *gin.Context) {
 
 // Update   	 godoc
 // @Summary      Update user by id
+// @Description  Changing the role signs the user out of every session, so that tokens carrying the old role stop working.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        id       path      int               true  "User ID"
 // @Param        request  body      updateUserRequest  true  "Update user payload"
+// @Param        If-Match  header  string  false  "ETag of the user as read; the request fails with 412 when it changed since"
 // @Success      200      {object}  nil               "OK"
 // @Failure      400      {object}  models.ApiError   "Invalid User Id / Could not update user"
+// @Failure      403      {object}  models.ApiError   "Not allowed to update this account or role"
+// @Failure      404      {object}  models.ApiError   "User not found"
+// @Failure      409      {object}  models.ApiError   "Email is already taken"
+// @Failure      412  {object}  models.ApiError "The user has been modified since it was read"
 // @Failure      500      {object}  models.ApiError
 // @Router       /users/{id} [put]
 // @Security Bearer
@@ -165,47 +225,69 @@ func (h *UsersHandlers) Update(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid User Id"))
+		c.Error(apperrors.Validation("Invalid User Id"))
 		return
 	}
 
-	_, err = h.repo.FindById(c, id)
+	if !canManageUser(c, id) {
+		c.Error(apperrors.Forbidden("You can only update your own account"))
+		return
+	}
+
+	existing, err := h.repo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
 	var request updateUserRequest
-	err = c.BindJSON(&request)
+	err = bindJSON(c, &request)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Could not update user"))
+		c.Error(err)
+		return
+	}
+
+	if request.Role != "" && c.GetString("userRole") != models.RoleAdmin {
+		c.Error(apperrors.Forbidden("Only administrators can change roles"))
 		return
 	}
 
 	user := models.User{
-		Id:    id,
-		Name:  request.Name,
-		Email: request.Email,
+		Id:      id,
+		Name:    request.Name,
+		Email:   request.Email,
+		Role:    request.Role,
+		Version: ifMatchVersion(c, existing.Version),
 	}
 
-	err = h.repo.Update(c, user)
+	version, err := h.repo.Update(c, user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Could not update user"))
+		c.Error(err)
 		return
 	}
 
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
 
 // ChangePassword   	 godoc
-// @Summary      Change user password
+// @Summary      Set another user's password
+// @Description  Administrative override that does not need the current password; users change their own password via /auth/changePassword. Signs the user out of every session.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        id       path      int                        true  "User ID"
 // @Param        request  body      changeUserPasswordRequest  true  "New password payload"
+// @Param        If-Match  header  string  false  "ETag of the user as read; the request fails with 412 when it changed since"
 // @Success      200      {object}  nil                        "OK"
 // @Failure      400      {object}  models.ApiError           "Invalid User Id / Invalid payload"
+// @Failure      403      {object}  models.ApiError           "Admin role required"
+// @Failure      404      {object}  models.ApiError           "User not found"
+// @Failure      412      {object}  models.ApiError           "The user has been modified since it was read"
 // @Failure      500      {object}  models.ApiError
 // @Router       /users/{id}/changePassword [patch]
 // @Security Bearer
@@ -214,51 +296,68 @@ func (h *UsersHandlers) ChangePassword(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid User Id"))
+		c.Error(apperrors.Validation("Invalid User Id"))
 		return
 	}
 
-	_, err = h.repo.FindById(c, id)
+	existing, err := h.repo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
 	var request changeUserPasswordRequest
-	err = c.BindJSON(&request)
+	err = bindJSON(c, &request)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid payload"))
+		c.Error(err)
 		return
 	}
 
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("Failed hash password"))
+		c.Error(err)
 		return
 	}
 
 	user := models.User{
 		Id:           id,
 		PasswordHash: string(passwordHash),
+		Version:      ifMatchVersion(c, existing.Version),
 	}
 
-	err = h.repo.ChangePassword(c, user)
+	version, err := h.repo.ChangePassword(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError("Could not change user password"))
+		c.Error(err)
 		return
 	}
 
+	err = h.tokensRepo.RevokeAllForUser(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, version)
 	c.Status(http.StatusOK)
 }
 
 // Delete   	 godoc
 // @Summary      Delete user by id
+// @Description  The user is soft deleted: the account can no longer sign in, and it can be restored until it is purged after the retention period.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "User ID"
+// @Param        If-Match  header  string  false  "ETag of the user as read; the request fails with 412 when it changed since"
 // @Success      200  {object}  nil "OK"
 // @Failure      400  {object}  models.ApiError "Invalid User Id"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "User not found"
+// @Failure      412  {object}  models.ApiError "The user has been modified since it was read"
 // @Failure      500  {object}  models.ApiError
 // @Router       /users/{id} [delete]
 // @Security Bearer
@@ -267,25 +366,64 @@ func (h *UsersHandlers) Delete(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid User Id"))
+		c.Error(apperrors.Validation("Invalid User Id"))
 		return
 	}
 
-	_, err = h.repo.FindById(c, id)
+	existing, err := h.repo.FindById(c, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
+		return
+	}
+	if !matchesVersion(c, existing.Version) {
+		versionMismatch(c)
 		return
 	}
 
-	err = h.repo.Delete(c, id)
+	err = h.repo.Delete(c, id, ifMatchVersion(c, existing.Version))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
+// Restore   	 godoc
+// @Summary      Restore deleted user
+// @Description  Sessions revoked by the deletion stay revoked, the user signs in again.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  "OK"
+// @Header       200  {string}  ETag "Version of the restored user, for If-Match"
+// @Failure      400  {object}  models.ApiError "Invalid User Id"
+// @Failure      403  {object}  models.ApiError "Admin role required"
+// @Failure      404  {object}  models.ApiError "User not found"
+// @Failure      409  {object}  models.ApiError "Email is already taken by another account"
+// @Failure      500  {object}  models.ApiError
+// @Router       /users/{id}/restore [post]
+// @Security Bearer
+func (h *UsersHandlers) Restore(c *gin.Context) {
+	idStr := c.Param("id")
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid User Id"))
+		return
+	}
+
+	version, err := h.repo.Restore(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setETag(c, version)
+	c.Status(http.StatusOK)
+}
+
 // GetUserInfo   godoc
 // @Summary      Get user info by userId
 // @Tags         users
@@ -300,7 +438,7 @@ func (h *UsersHandlers) GetUserInfo(c *gin.Context) {
 	userId := c.GetInt("userId")
 	user, err := h.repo.FindById(c, userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
@@ -308,5 +446,11 @@ func (h *UsersHandlers) GetUserInfo(c *gin.Context) {
 		Id:    user.Id,
 		Email: user.Email,
 		Name:  user.Name,
+		Role:  user.Role,
 	})
 }
+
+// canManageUser reports whether the caller may read or edit the account with the given id.
+func canManageUser(c *gin.Context, id int) bool {
+	return c.GetInt("userId") == id || c.GetString("userRole") == models.RoleAdmin
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/config"
+	"filmservice/models"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxPasswordBytes is the most bcrypt hashes, longer passwords would be silently truncated.
+const maxPasswordBytes = 72
+
+func init() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// Field errors use the names clients send, not the Go field names.
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+
+		return strings.ToLower(field.Name[:1]) + field.Name[1:]
+	})
+
+	engine.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return isValidPassword(fl.Field().String())
+	})
+}
+
+// bindJSON decodes and validates a JSON body, reporting every invalid field at once.
+func bindJSON(c *gin.Context, request any) error {
+	return bindingError(c.ShouldBindJSON(request))
+}
+
+// bind decodes and validates a body of the request's content type, e.g. a multipart form.
+func bind(c *gin.Context, request any) error {
+	return bindingError(c.ShouldBind(request))
+}
+
+// validate checks the binding rules of a request decoded by hand.
+func validate(request any) error {
+	return bindingError(binding.Validator.ValidateStruct(request))
+}
+
+func bindingError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]models.FieldError, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			fields = append(fields, apperrors.Field(fieldError.Field(), validationMessage(fieldError)))
+		}
+
+		return apperrors.Validation("Request has invalid fields", fields...)
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return apperrors.InvalidField(typeError.Field, "must be of type "+typeError.Type.String())
+	}
+
+	return apperrors.Validation("Invalid request payload").Wrap(err)
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "password":
+		return passwordPolicy()
+	case "gt":
+		return "must be greater than " + param
+	case "min", "max":
+		bound := "at least"
+		if fieldError.Tag() == "max" {
+			bound = "at most"
+		}
+
+		switch fieldError.Kind() {
+		// The validator counts the runes of strings, so their bounds are in characters.
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", bound, param)
+		case reflect.Slice, reflect.Array:
+			return fmt.Sprintf("must contain %s %s items", bound, param)
+		default:
+			return fmt.Sprintf("must be %s %s", bound, param)
+		}
+	default:
+		return "is invalid"
+	}
+}
+
+// isValidPassword applies the password policy configured with the PASSWORD_* settings.
+func isValidPassword(password string) bool {
+	if len([]rune(password)) < config.Config.PasswordMinLength || len(password) > maxPasswordBytes {
+		return false
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	return (!config.Config.PasswordRequireMixedCase || hasUpper && hasLower) &&
+		(!config.Config.PasswordRequireDigit || hasDigit) &&
+		(!config.Config.PasswordRequireSymbol || hasSymbol)
+}
+
+// passwordPolicy describes the configured password policy, e.g. "must be at least 8 characters long,
+// at most 72 bytes and contain a digit". The upper bound is in bytes, since that is what bcrypt limits.
+func passwordPolicy() string {
+	rules := []string{
+		fmt.Sprintf("must be at least %d characters long", config.Config.PasswordMinLength),
+		fmt.Sprintf("at most %d bytes", maxPasswordBytes),
+	}
+
+	if config.Config.PasswordRequireMixedCase {
+		rules = append(rules, "contain upper and lower case letters")
+	}
+	if config.Config.PasswordRequireDigit {
+		rules = append(rules, "contain a digit")
+	}
+	if config.Config.PasswordRequireSymbol {
+		rules = append(rules, "contain a symbol")
+	}
+
+	return strings.Join(rules[:len(rules)-1], ", ") + " and " + rules[len(rules)-1]
+}
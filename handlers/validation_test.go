@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"filmservice/config"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	previous := config.Config
+	t.Cleanup(func() { config.Config = previous })
+
+	config.Config = &config.MapConfig{PasswordMinLength: 8}
+	want := "must be at least 8 characters long and at most 72 bytes"
+	if got := passwordPolicy(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{password: "short", valid: false},
+		{password: "ãããããããã", valid: true},
+		{password: strings.Repeat("a", 72), valid: true},
+		{password: strings.Repeat("a", 73), valid: false},
+		// 36 characters, but 108 bytes.
+		{password: strings.Repeat("€", 36), valid: false},
+	}
+
+	for _, tt := range tests {
+		if got := isValidPassword(tt.password); got != tt.valid {
+			t.Errorf("isValidPassword(%q) = %v, want %v", tt.password, got, tt.valid)
+		}
+	}
+
+	config.Config = &config.MapConfig{PasswordMinLength: 12, PasswordRequireDigit: true, PasswordRequireSymbol: true}
+	want = "must be at least 12 characters long, at most 72 bytes, contain a digit and contain a symbol"
+	if got := passwordPolicy(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
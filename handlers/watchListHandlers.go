@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"filmservice/apperrors"
 	"filmservice/models"
 	"filmservice/repositories"
 	"net/http"
@@ -20,18 +21,31 @@ func NewWatchListHandlers(watchListRepo *repositories.WatchListRepository) *Watc
 }
 
 // GetAll   	 godoc
-// @Summary      Get all movies from watchlist
+// @Summary      Get all movies from current user's watchlist
 // @Tags         watchlist
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Movie "OK"
+// @Param        limit   query     int     false  "Page size (1-100, default 20)"
+// @Param        cursor  query     string  false  "nextCursor of the previous page"
+// @Param        offset  query     int     false  "Rows to skip, ignored when a cursor is given"
+// @Success      200  {object}  models.Page[models.Movie] "OK"
+// @Failure      400  {object}  models.ApiError "Invalid page parameters"
 // @Failure      500  {object}  models.ApiError
 // @Router       /watchlist [get]
 // @Security Bearer
 func (h *WatchListHandlers) GetAll(c *gin.Context) {
-	movies, err := h.watchListRepo.GetAll(c)
+	page, err := parsePageRequest(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError(err.Error()))
+		c.Error(err)
+		return
+	}
+
+	userId := c.GetInt("userId")
+
+	var movies models.Page[models.Movie]
+	movies, err = h.watchListRepo.GetAll(c, userId, page)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -43,7 +57,7 @@ func (h *WatchListHandlers) parseMovieId(c *gin.Context) (int, bool) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewApiError("Invalid Movie Id"))
+		c.Error(apperrors.Validation("Invalid Movie Id"))
 		return 0, false
 	}
 
@@ -51,13 +65,14 @@ func (h *WatchListHandlers) parseMovieId(c *gin.Context) (int, bool) {
 }
 
 // Toggle   	 godoc
-// @Summary      Add or remove movie from watchlist (toggle)
+// @Summary      Add or remove movie from current user's watchlist (toggle)
 // @Tags         watchlist
 // @Accept       json
 // @Produce      json
 // @Param        movieId   path      int  true  "Movie ID"
 // @Success      204  "No Content"
 // @Failure      400  {object}  models.ApiError "Invalid Movie Id"
+// @Failure      404  {object}  models.ApiError "Movie not found"
 // @Failure      500  {object}  models.ApiError
 // @Router       /watchlist/{movieId} [post]
 // @Security Bearer
@@ -67,20 +82,22 @@ func (h *WatchListHandlers) Toggle(c *gin.Context) {
 		return
 	}
 
-	exists, err := h.watchListRepo.Exists(c, id)
+	userId := c.GetInt("userId")
+
+	exists, err := h.watchListRepo.Exists(c, userId, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
 	if exists {
-		err = h.watchListRepo.Delete(c, id)
+		err = h.watchListRepo.Delete(c, userId, id)
 	} else {
-		err = h.watchListRepo.Add(c, id)
+		err = h.watchListRepo.Add(c, userId, id)
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
@@ -88,7 +105,7 @@ func (h *WatchListHandlers) Toggle(c *gin.Context) {
 }
 
 // Delete   	 godoc
-// @Summary      Remove movie from watchlist
+// @Summary      Remove movie from current user's watchlist
 // @Tags         watchlist
 // @Accept       json
 // @Produce      json
@@ -104,9 +121,11 @@ func (h *WatchListHandlers) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.watchListRepo.Delete(c, id)
+	userId := c.GetInt("userId")
+
+	err := h.watchListRepo.Delete(c, userId, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.NewApiError(err.Error()))
+		c.Error(err)
 		return
 	}
 
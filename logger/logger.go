@@ -1,24 +1,87 @@
 package logger2
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
 	instance *zap.Logger
-	once     sync.Once
+	mu       sync.RWMutex
 )
 
+type contextKey struct{}
+
+// New builds a logger writing at the given level, e.g. "debug" or "info", in the given format:
+// "json" for log collectors or "console" for humans.
+func New(level string, format string) (*zap.Logger, error) {
+	var config zap.Config
+	switch format {
+	case "json":
+		config = zap.NewProductionConfig()
+	case "console":
+		config = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	logLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	config.Level = zap.NewAtomicLevelAt(logLevel)
+
+	return config.Build()
+}
+
+// Init replaces the logger returned by GetLogger with one built by New.
+func Init(level string, format string) (*zap.Logger, error) {
+	logger, err := New(level, format)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	instance = logger
+
+	return logger, nil
+}
+
+// GetLogger returns the application logger, a production logger until Init configures it.
 func GetLogger() *zap.Logger {
-	once.Do(
-		func() {
-			logger, _ := zap.NewProduction()
-			defer logger.Sync()
-			instance = logger
-		},
-	)
+	mu.RLock()
+	logger := instance
+	mu.RUnlock()
+	if logger != nil {
+		return logger
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if instance == nil {
+		instance = zap.Must(zap.NewProduction())
+	}
 
 	return instance
 }
+
+// WithContext stores a logger, usually one carrying request fields, in the context.
+func WithContext(c context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(c, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in the context, or the application logger.
+func FromContext(c context.Context) *zap.Logger {
+	if c != nil {
+		if logger, ok := c.Value(contextKey{}).(*zap.Logger); ok {
+			return logger
+		}
+	}
+
+	return GetLogger()
+}
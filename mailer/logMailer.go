@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer does not deliver anything. It appends messages to a file, or logs them when no file is set,
+// which makes it suitable for local development and tests.
+type LogMailer struct {
+	path   string
+	logger *zap.Logger
+	mu     sync.Mutex
+}
+
+func NewLogMailer(path string, logger *zap.Logger) *LogMailer {
+	return &LogMailer{
+		path:   path,
+		logger: logger,
+	}
+}
+
+func (m *LogMailer) Send(c context.Context, message Message) error {
+	if m.path == "" {
+		m.logger.Info("mail message",
+			zap.String("to", message.To),
+			zap.String("subject", message.Subject),
+			zap.String("body", message.Body),
+		)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), message.To, message.Subject, message.Body)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
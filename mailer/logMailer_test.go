@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogMailerAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewLogMailer(path, zap.NewNop())
+
+	messages := []Message{
+		{To: "a@example.com", Subject: "Verify your email", Body: "token-a"},
+		{To: "b@example.com", Subject: "Reset your password", Body: "token-b"},
+	}
+	for _, message := range messages {
+		err := m.Send(context.Background(), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"To: a@example.com\nSubject: Verify your email\n\ntoken-a\n",
+		"To: b@example.com\nSubject: Reset your password\n\ntoken-b\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("mail log lacks %q:\n%s", want, content)
+		}
+	}
+	if strings.Index(string(content), "token-a") > strings.Index(string(content), "token-b") {
+		t.Error("messages are not appended in the order they were sent")
+	}
+}
+
+func TestLogMailerLogsWithoutFile(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	m := NewLogMailer("", zap.New(core))
+
+	err := m.Send(context.Background(), Message{To: "a@example.com", Subject: "Hi", Body: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.FilterMessage("mail message").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["to"] != "a@example.com" || fields["subject"] != "Hi" || fields["body"] != "token" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
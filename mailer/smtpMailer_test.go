@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSMTPMailerRejectsInvalidSender(t *testing.T) {
+	_, err := NewSMTPMailer("localhost", 25, "", "", "not an address", time.Second)
+	if err == nil {
+		t.Fatal("expected an error for an invalid sender address")
+	}
+}
+
+func TestSMTPMailerSendsBareEnvelopeAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	transcript := make(chan []string, 1)
+	go serveSMTP(t, listener, transcript)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m, err := NewSMTPMailer("127.0.0.1", addr.Port, "", "", "FilmService <no-reply@filmservice.local>", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-transcript
+	if !contains(lines, "MAIL FROM:<no-reply@filmservice.local>") {
+		t.Errorf("envelope sender is not the bare address, transcript: %q", lines)
+	}
+	if !contains(lines, `From: "FilmService" <no-reply@filmservice.local>`) {
+		t.Errorf("From header lost the display name, transcript: %q", lines)
+	}
+}
+
+func TestSMTPMailerTimesOut(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// The server accepts the connection but never greets the client.
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m, err := NewSMTPMailer("127.0.0.1", addr.Port, "", "", "no-reply@filmservice.local", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "Hi"})
+	if err == nil {
+		t.Fatal("expected the send to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the send gave up after %s", elapsed)
+	}
+}
+
+func contains(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveSMTP answers a single session with the least a client needs, and reports every line it received.
+func serveSMTP(t *testing.T, listener net.Listener, transcript chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Error(err)
+		transcript <- nil
+		return
+	}
+	defer conn.Close()
+
+	lines := make([]string, 0)
+	defer func() { transcript <- lines }()
+
+	r := bufio.NewReader(conn)
+	reply := func(code int, text string) {
+		conn.Write([]byte(strconv.Itoa(code) + " " + text + "\r\n"))
+	}
+
+	reply(220, "localhost ESMTP")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+
+		switch {
+		case inData:
+			if line == "." {
+				inData = false
+				reply(250, "queued")
+			}
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply(250, "localhost")
+		case line == "DATA":
+			inData = true
+			reply(354, "go ahead")
+		case line == "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(250, "ok")
+		}
+	}
+}
@@ -1,42 +1,86 @@
 package middlewares
 
 import (
+	"filmservice/apperrors"
 	"filmservice/config"
+	logger2 "filmservice/logger"
 	"filmservice/models"
-	"net/http"
+	"filmservice/repositories"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 )
 
-func AuthMiddleware(c *gin.Context) {
+func AuthMiddleware(tokensRepo *repositories.TokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(c, tokensRepo)
+	}
+}
+
+func authenticate(c *gin.Context, tokensRepo *repositories.TokensRepository) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, models.NewApiError("authorization header required"))
+		c.Error(apperrors.Unauthorized("authorization header required"))
 		c.Abort()
 		return
 	}
 
-	tokenString := strings.Split(authHeader, "Bearer ")[1]
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	tokenString, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found {
+		c.Error(apperrors.Unauthorized("invalid authorization header"))
+		c.Abort()
+		return
+	}
+
+	var claims models.Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.Config.JwtSecretKey), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, models.NewApiError("invalid token"))
+		c.Error(apperrors.Unauthorized("invalid token"))
 		c.Abort()
 		return
 	}
 
 	subject, err := token.Claims.GetSubject()
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.NewApiError("error while getting subject"))
+		c.Error(apperrors.Unauthorized("error while getting subject"))
+		c.Abort()
+		return
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		c.Error(apperrors.Unauthorized("invalid token"))
 		c.Abort()
 		return
 	}
 
+	revoked, err := tokensRepo.IsRevoked(c, claims.ID)
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	if revoked {
+		c.Error(apperrors.Unauthorized("token has been revoked"))
+		c.Abort()
+		return
+	}
+
+	// Tokens issued before roles existed carry no role claim.
+	role := claims.Role
+	if role == "" {
+		role = models.RoleViewer
+	}
+
 	userId, _ := strconv.Atoi(subject)
 	c.Set("userId", userId)
+	c.Set("userRole", role)
+	c.Set("tokenId", claims.ID)
+	c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+	setLogger(c, logger2.FromContext(c).With(zap.Int("userId", userId)))
 	c.Next()
 }
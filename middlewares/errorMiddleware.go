@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"errors"
+	"filmservice/apperrors"
+	logger2 "filmservice/logger"
+	"filmservice/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const problemContentType = "application/problem+json"
+
+// ErrorHandler renders the first error a handler attached with c.Error as an RFC 7807 problem.
+// Domain errors keep their status and message; anything else is logged and hidden behind a 500.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors[0].Err
+		problem := models.ApiError{
+			Type:      "about:blank",
+			Instance:  c.Request.URL.Path,
+			RequestId: c.GetString("requestId"),
+		}
+
+		var appErr *apperrors.Error
+		if errors.As(err, &appErr) && appErr.Kind != apperrors.KindInternal {
+			problem.Status = appErr.Status()
+			problem.Detail = appErr.Message
+			problem.Errors = appErr.Fields
+		} else {
+			logger2.FromContext(c).Error(
+				"request failed",
+				zap.Error(err),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+
+			problem.Status = http.StatusInternalServerError
+			problem.Detail = "Something went wrong, try again later"
+		}
+		problem.Title = http.StatusText(problem.Status)
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
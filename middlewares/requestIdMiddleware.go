@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	logger2 "filmservice/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+	// maxRequestIdLength is the width of audit_events.request_id; longer ids are replaced by generated ones.
+	maxRequestIdLength = 64
+)
+
+// RequestId tags every request with an id, returned in the X-Request-ID header and in error responses,
+// so that a client report can be matched with the logs. An id sent by a client or proxy is kept, so that
+// a request can be followed across services. The request context carries a logger with the id.
+func RequestId(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIdHeader)
+		if !isValidRequestId(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		c.Set("requestId", requestId)
+		c.Header(RequestIdHeader, requestId)
+		setLogger(c, logger.With(zap.String("requestId", requestId)))
+
+		c.Next()
+	}
+}
+
+// isValidRequestId accepts ids made of letters, digits and -_.: only, which are safe to log and echo.
+func isValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	for _, r := range id {
+		valid := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '-' || r == '_' || r == '.' || r == ':'
+		if !valid {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setLogger makes logger the one logger2.FromContext returns for the rest of the request.
+func setLogger(c *gin.Context, logger *zap.Logger) {
+	c.Request = c.Request.WithContext(logger2.WithContext(c.Request.Context(), logger))
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{name: "missing", incoming: "", kept: false},
+		{name: "uuid", incoming: "9b1c2f0e-5d1a-4c57-8f37-1e4f0c9a2b6d", kept: true},
+		{name: "allowed symbols", incoming: "svc-a:trace_1.2", kept: true},
+		{name: "as long as the audit column", incoming: strings.Repeat("a", 64), kept: true},
+		{name: "longer than the audit column", incoming: strings.Repeat("a", 65), kept: false},
+		{name: "far too long", incoming: strings.Repeat("a", 128), kept: false},
+		{name: "space", incoming: "a b", kept: false},
+		{name: "newline", incoming: "a\nb", kept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.Use(RequestId(zap.NewNop()))
+			r.GET("/", func(c *gin.Context) {
+				seen = c.GetString("requestId")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIdHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			returned := w.Header().Get(RequestIdHeader)
+			if returned != seen {
+				t.Errorf("header %q differs from context value %q", returned, seen)
+			}
+			if len(seen) == 0 || len(seen) > maxRequestIdLength {
+				t.Errorf("request id %q is empty or longer than %d", seen, maxRequestIdLength)
+			}
+			if kept := seen == tt.incoming; kept != tt.kept {
+				t.Errorf("incoming id kept = %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"filmservice/apperrors"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole must run after AuthMiddleware, which puts the caller's role into the context.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("userRole")) {
+			c.Error(apperrors.Forbidden("insufficient permissions"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
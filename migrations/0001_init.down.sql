@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS public.watch_list;
+DROP SEQUENCE IF EXISTS public.watch_queue_id_seq;
+DROP TABLE IF EXISTS public.movies_genres;
+DROP TABLE IF EXISTS public.users;
+DROP TABLE IF EXISTS public.movies;
+DROP TABLE IF EXISTS public.genres;
@@ -1,10 +1,12 @@
-CREATE TABLE public.genres (
+-- Baseline schema. Statements are idempotent so databases created by the
+-- former init.sql are adopted without changes.
+CREATE TABLE IF NOT EXISTS public.genres (
 	id serial4 NOT NULL,
 	title text NULL,
 	CONSTRAINT genres_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.movies (
+CREATE TABLE IF NOT EXISTS public.movies (
 	id serial4 NOT NULL,
 	title text NULL,
 	description text NULL,
@@ -17,7 +19,7 @@ CREATE TABLE public.movies (
 	CONSTRAINT movies_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.users (
+CREATE TABLE IF NOT EXISTS public.users (
 	id serial4 NOT NULL,
 	"name" text NOT NULL,
 	email text NOT NULL,
@@ -26,14 +28,16 @@ CREATE TABLE public.users (
 	CONSTRAINT users_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.movies_genres (
+CREATE TABLE IF NOT EXISTS public.movies_genres (
 	movie_id int4 NULL,
 	genre_id int4 NULL,
 	CONSTRAINT movies_genres_genre_id_fkey FOREIGN KEY (genre_id) REFERENCES public.genres(id),
 	CONSTRAINT movies_genres_movies_id_fkey FOREIGN KEY (movie_id) REFERENCES public.movies(id)
 );
 
-CREATE TABLE public.watch_list (
+CREATE SEQUENCE IF NOT EXISTS public.watch_queue_id_seq AS int4;
+
+CREATE TABLE IF NOT EXISTS public.watch_list (
 	id int4 DEFAULT nextval('watch_queue_id_seq'::regclass) NOT NULL,
 	movie_id int4 NOT NULL,
 	added_at timestamp DEFAULT now() NULL,
@@ -41,5 +45,8 @@ CREATE TABLE public.watch_list (
 	CONSTRAINT watch_queue_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES public.movies(id)
 );
 
-insert into users (name, email, password_hash)
-values ('test', 'test@test.kz', '$2a$10$icUrnO4tI.v6JHXMNe4MR.TO0LPFNcq5clSbnU5RzD.o8zaeQnHCW')
\ No newline at end of file
+ALTER SEQUENCE public.watch_queue_id_seq OWNED BY public.watch_list.id;
+
+INSERT INTO public.users (name, email, password_hash)
+VALUES ('test', 'test@test.kz', '$2a$10$icUrnO4tI.v6JHXMNe4MR.TO0LPFNcq5clSbnU5RzD.o8zaeQnHCW')
+ON CONFLICT (email) DO NOTHING;
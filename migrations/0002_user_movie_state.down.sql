@@ -0,0 +1,14 @@
+ALTER TABLE public.movies
+	ADD COLUMN IF NOT EXISTS rating int4 DEFAULT 0 NULL,
+	ADD COLUMN IF NOT EXISTS is_watched bool DEFAULT false NULL;
+
+-- The shared columns hold one value per movie, so they get back the designated
+-- owner's ratings and watched flags, the ones the up migration assigned to it.
+UPDATE public.movies m
+SET rating = COALESCE(s.rating, 0),
+	is_watched = s.is_watched
+FROM public.user_movie_state s
+WHERE s.movie_id = m.id
+  AND s.user_id = (SELECT id FROM public.users ORDER BY id LIMIT 1);
+
+DROP TABLE IF EXISTS public.user_movie_state;
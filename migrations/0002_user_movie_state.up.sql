@@ -0,0 +1,40 @@
+-- Ratings and watched flags belong to a user instead of the shared movies row.
+CREATE TABLE IF NOT EXISTS public.user_movie_state (
+	user_id int4 NOT NULL,
+	movie_id int4 NOT NULL,
+	rating int4 NULL,
+	is_watched bool DEFAULT false NOT NULL,
+	updated_at timestamp DEFAULT now() NOT NULL,
+	CONSTRAINT user_movie_state_pkey PRIMARY KEY (user_id, movie_id),
+	CONSTRAINT user_movie_state_rating_check CHECK (rating BETWEEN 1 AND 5),
+	CONSTRAINT user_movie_state_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+	CONSTRAINT user_movie_state_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES public.movies(id)
+);
+
+CREATE INDEX IF NOT EXISTS user_movie_state_movie_id_idx ON public.user_movie_state (movie_id);
+
+-- Ratings and watched flags set while they were shared are assigned to the
+-- designated owner, the oldest account, as watch lists are. A rating of 0 meant
+-- unrated; the IF EXISTS guard keeps the migration rerunnable after the drop.
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = 'movies' AND column_name = 'rating'
+	) THEN
+		INSERT INTO public.user_movie_state (user_id, movie_id, rating, is_watched)
+		SELECT
+			(SELECT id FROM public.users ORDER BY id LIMIT 1),
+			m.id,
+			CASE WHEN m.rating BETWEEN 1 AND 5 THEN m.rating END,
+			COALESCE(m.is_watched, false)
+		FROM public.movies m
+		WHERE EXISTS (SELECT 1 FROM public.users)
+		  AND (m.rating BETWEEN 1 AND 5 OR m.is_watched)
+		ON CONFLICT (user_id, movie_id) DO NOTHING;
+	END IF;
+END $$;
+
+ALTER TABLE public.movies
+	DROP COLUMN IF EXISTS rating,
+	DROP COLUMN IF EXISTS is_watched;
@@ -0,0 +1,4 @@
+ALTER TABLE public.watch_list
+	DROP CONSTRAINT IF EXISTS watch_list_user_movie_key,
+	DROP CONSTRAINT IF EXISTS watch_list_user_id_fkey,
+	DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,25 @@
+-- Watch lists are owned by a user. Rows created while the list was global are
+-- assigned to the designated owner: the oldest account, which is the seeded
+-- test@test.kz user on a default install.
+ALTER TABLE public.watch_list ADD COLUMN IF NOT EXISTS user_id int4 NULL;
+
+UPDATE public.watch_list
+SET user_id = (SELECT id FROM public.users ORDER BY id LIMIT 1)
+WHERE user_id IS NULL;
+
+-- Nothing to assign the rows to when there are no users at all.
+DELETE FROM public.watch_list WHERE user_id IS NULL;
+
+DELETE FROM public.watch_list a
+USING public.watch_list b
+WHERE a.user_id = b.user_id
+  AND a.movie_id = b.movie_id
+  AND a.id > b.id;
+
+ALTER TABLE public.watch_list ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE public.watch_list
+	DROP CONSTRAINT IF EXISTS watch_list_user_movie_key,
+	DROP CONSTRAINT IF EXISTS watch_list_user_id_fkey,
+	ADD CONSTRAINT watch_list_user_movie_key UNIQUE (user_id, movie_id),
+	ADD CONSTRAINT watch_list_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE public.users
+	DROP CONSTRAINT IF EXISTS users_role_check,
+	DROP COLUMN IF EXISTS "role";
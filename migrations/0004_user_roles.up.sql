@@ -0,0 +1,11 @@
+-- Roles gate catalog and account management. The oldest account (the seeded
+-- test@test.kz user on a default install) becomes the first administrator.
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS "role" text DEFAULT 'viewer' NOT NULL;
+
+ALTER TABLE public.users
+	DROP CONSTRAINT IF EXISTS users_role_check,
+	ADD CONSTRAINT users_role_check CHECK ("role" IN ('admin', 'viewer'));
+
+UPDATE public.users
+SET "role" = 'admin'
+WHERE id = (SELECT id FROM public.users ORDER BY id LIMIT 1);
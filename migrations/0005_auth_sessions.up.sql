@@ -0,0 +1,28 @@
+-- Refresh tokens are stored hashed; each row is one sign-in session and remembers
+-- the access token issued with it so that revoking the session revokes both.
+CREATE TABLE IF NOT EXISTS public.refresh_tokens (
+	id serial4 NOT NULL,
+	user_id int4 NOT NULL,
+	token_hash text NOT NULL,
+	access_token_id text NOT NULL,
+	access_expires_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	revoked_at timestamptz NULL,
+	CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id),
+	CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash),
+	CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON public.refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_access_token_id_idx ON public.refresh_tokens (access_token_id);
+
+-- Access tokens (by jti) rejected by AuthMiddleware until they would have expired anyway.
+CREATE TABLE IF NOT EXISTS public.revoked_tokens (
+	jti text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	revoked_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT revoked_tokens_pkey PRIMARY KEY (jti)
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON public.revoked_tokens (expires_at);
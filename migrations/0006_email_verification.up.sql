@@ -0,0 +1,20 @@
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz NULL;
+
+-- Accounts that exist before self-service sign-up were created by an administrator.
+UPDATE public.users SET email_verified_at = now() WHERE email_verified_at IS NULL;
+
+-- Single-use tokens mailed to users; only their hashes are stored.
+CREATE TABLE IF NOT EXISTS public.user_tokens (
+	id serial4 NOT NULL,
+	user_id int4 NOT NULL,
+	purpose text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT user_tokens_pkey PRIMARY KEY (id),
+	CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+	CONSTRAINT user_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_purpose_idx ON public.user_tokens (user_id, purpose);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS public.movies_title_trgm_idx;
+DROP INDEX IF EXISTS public.movies_search_vector_idx;
+
+DROP TRIGGER IF EXISTS movies_search_vector_trigger ON public.movies;
+DROP FUNCTION IF EXISTS public.movies_search_vector_update();
+
+ALTER TABLE public.movies DROP COLUMN IF EXISTS search_vector;
+
+-- pg_trgm is left installed, other objects in the database may depend on it.
@@ -0,0 +1,28 @@
+-- Full-text search over title, director and description, plus trigram matching for typos in titles.
+-- The 'simple' configuration does no stemming, so titles and names in any language match as written.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE public.movies ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION public.movies_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(NEW.director, '')), 'B') ||
+		setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'C');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_search_vector_trigger ON public.movies;
+CREATE TRIGGER movies_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF title, director, description ON public.movies
+	FOR EACH ROW EXECUTE FUNCTION public.movies_search_vector_update();
+
+UPDATE public.movies SET search_vector =
+	setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(director, '')), 'B') ||
+	setweight(to_tsvector('simple', coalesce(description, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS movies_search_vector_idx ON public.movies USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS movies_title_trgm_idx ON public.movies USING gin (title gin_trgm_ops);
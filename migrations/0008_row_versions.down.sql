@@ -0,0 +1,11 @@
+ALTER TABLE public.users
+	DROP COLUMN IF EXISTS updated_at,
+	DROP COLUMN IF EXISTS version;
+
+ALTER TABLE public.genres
+	DROP COLUMN IF EXISTS updated_at,
+	DROP COLUMN IF EXISTS version;
+
+ALTER TABLE public.movies
+	DROP COLUMN IF EXISTS updated_at,
+	DROP COLUMN IF EXISTS version;
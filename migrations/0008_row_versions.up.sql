@@ -0,0 +1,13 @@
+-- Row versions for optimistic concurrency control: every update increments version,
+-- which clients send back in If-Match.
+ALTER TABLE public.movies
+	ADD COLUMN IF NOT EXISTS version int4 DEFAULT 1 NOT NULL,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+
+ALTER TABLE public.genres
+	ADD COLUMN IF NOT EXISTS version int4 DEFAULT 1 NOT NULL,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+
+ALTER TABLE public.users
+	ADD COLUMN IF NOT EXISTS version int4 DEFAULT 1 NOT NULL,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
@@ -0,0 +1,27 @@
+-- Soft deleted rows are removed first, they would otherwise reappear as active ones.
+DELETE FROM public.watch_list WHERE movie_id IN (SELECT id FROM public.movies WHERE deleted_at IS NOT NULL);
+DELETE FROM public.user_movie_state WHERE movie_id IN (SELECT id FROM public.movies WHERE deleted_at IS NOT NULL);
+DELETE FROM public.movies_genres
+WHERE movie_id IN (SELECT id FROM public.movies WHERE deleted_at IS NOT NULL)
+	OR genre_id IN (SELECT id FROM public.genres WHERE deleted_at IS NOT NULL);
+DELETE FROM public.movies WHERE deleted_at IS NOT NULL;
+DELETE FROM public.genres WHERE deleted_at IS NOT NULL;
+DELETE FROM public.users WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS public.users_email_key;
+
+ALTER TABLE public.users
+	ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS public.users_deleted_at_idx;
+DROP INDEX IF EXISTS public.genres_deleted_at_idx;
+DROP INDEX IF EXISTS public.movies_deleted_at_idx;
+
+ALTER TABLE public.users
+	DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE public.genres
+	DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE public.movies
+	DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,20 @@
+-- Soft deletion: deleted rows keep their deleted_at until the purge job removes them
+-- after the retention period, so they can be restored until then.
+ALTER TABLE public.movies
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE public.genres
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE public.users
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS movies_deleted_at_idx ON public.movies (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS genres_deleted_at_idx ON public.genres (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON public.users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Only active accounts claim their email, so it can be registered again after a deletion.
+ALTER TABLE public.users
+	DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON public.users (email) WHERE deleted_at IS NULL;
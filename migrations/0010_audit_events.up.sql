@@ -0,0 +1,19 @@
+-- Audit trail of catalog and account changes, written in the transaction of the change itself.
+-- actor_id has no foreign key, so events outlive the users they name.
+CREATE TABLE IF NOT EXISTS public.audit_events (
+	id bigserial NOT NULL,
+	occurred_at timestamptz DEFAULT now() NOT NULL,
+	actor_id int4 NULL,
+	action varchar(32) NOT NULL,
+	entity varchar(32) NOT NULL,
+	entity_id int4 NOT NULL,
+	"before" jsonb NULL,
+	"after" jsonb NULL,
+	request_id varchar(64) NULL,
+	ip varchar(45) NULL,
+	CONSTRAINT audit_events_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_idx ON public.audit_events (occurred_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON public.audit_events (actor_id, id);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON public.audit_events (entity, entity_id, id);
@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadOrdersByNumericVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10_tenth.up.sql":   {Data: []byte("up 10")},
+		"10_tenth.down.sql": {Data: []byte("down 10")},
+		"2_second.up.sql":   {Data: []byte("up 2")},
+		"2_second.down.sql": {Data: []byte("down 2")},
+		"1_first.up.sql":    {Data: []byte("up 1")},
+		"1_first.down.sql":  {Data: []byte("down 1")},
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "tenth", Up: "up 10", Down: "down 10"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadRejectsInvalidSets(t *testing.T) {
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		error string
+	}{
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("up")},
+			},
+			error: "must have both up and down files",
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":    {Data: []byte("up")},
+				"0001_other.down.sql": {Data: []byte("down")},
+			},
+			error: "conflicting names",
+		},
+		{
+			name: "unknown direction",
+			fsys: fstest.MapFS{
+				"0001_init.sideways.sql": {Data: []byte("up")},
+			},
+			error: "invalid migration file name",
+		},
+		{
+			name: "no name",
+			fsys: fstest.MapFS{
+				"0001.up.sql": {Data: []byte("up")},
+			},
+			error: "invalid migration file name",
+		},
+		{
+			name: "non-numeric version",
+			fsys: fstest.MapFS{
+				"first_init.up.sql": {Data: []byte("up")},
+			},
+			error: "invalid migration version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("got error %v, want one containing %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %d_%s is out of sequence at position %d", migration.Version, migration.Name, i)
+		}
+	}
+}
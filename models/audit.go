@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records one change of a movie, genre or user. Before and After hold the changed fields,
+// or the whole entity when it was created or purged.
+type AuditEvent struct {
+	Id         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	ActorId    *int            `json:"actorId"`
+	Action     string          `json:"action"`
+	Entity     string          `json:"entity"`
+	EntityId   int             `json:"entityId"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	RequestId  string          `json:"requestId,omitempty"`
+	Ip         string          `json:"ip,omitempty"`
+}
+
+type AuditFilters struct {
+	ActorId  *int
+	Entity   string
+	EntityId *int
+	Action   string
+	From     *time.Time
+	To       *time.Time
+}
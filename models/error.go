@@ -1,9 +1,17 @@
 package models
 
+// ApiError is an RFC 7807 problem details document, served as application/problem+json.
 type ApiError struct {
-	Error string
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestId string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
-func NewApiError(msg string) ApiError {
-	return ApiError{msg}
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
@@ -1,6 +1,11 @@
 package models
 
+import "time"
+
 type Genre struct {
-	Id    int
-	Title string
+	Id        int
+	Title     string
+	Version   int
+	UpdatedAt time.Time
+	DeletedAt *time.Time `json:",omitempty"`
 }
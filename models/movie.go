@@ -1,21 +1,68 @@
 package models
 
+import "time"
+
+const (
+	GenreModeAny = "any"
+	GenreModeAll = "all"
+)
+
 type Movie struct {
-	Id          int
+	Id            int
+	Title         string
+	Description   string
+	ReleaseYear   int
+	Director      string
+	Rating        int
+	IsWatched     bool
+	AverageRating float64
+	RatingsCount  int
+	TrailerUrl    string
+	Genres        []Genre
+	PosterUrl     string
+	Version       int
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time      `json:",omitempty"`
+	Highlight     *MovieHighlight `json:",omitempty"`
+}
+
+// MovieHighlight holds the fields matched by a search as HTML: the text is escaped and matches
+// are wrapped in <mark> tags.
+type MovieHighlight struct {
 	Title       string
-	Description string
-	ReleaseYear int
 	Director    string
-	Rating      int
-	IsWatched   bool
-	TrailerUrl  string
-	Genres      []Genre
-	PosterUrl   string
+	Description string
+}
+
+// MoviePatch lists the movie fields to change; nil fields are left untouched.
+type MoviePatch struct {
+	Title       *string `binding:"omitnil,max=255"`
+	Description *string `binding:"omitzero,max=5000"`
+	ReleaseYear *int    `binding:"omitnil,min=1888,max=2100"`
+	Director    *string `binding:"omitzero,max=255"`
+	TrailerUrl  *string `binding:"omitzero,url,max=2048"`
+	PosterUrl   *string
+	Genres      *[]Genre
+	// Version is the version the client expects the movie to have, or 0 to skip the check.
+	Version int
 }
 
 type MovieFilters struct {
 	SearchTerm string
-	GenreId    string
-	IsWatched  string
-	Sort       string
+	GenreIds   []int
+	GenreMode  string
+	IsWatched  *bool
+	YearFrom   *int
+	YearTo     *int
+	Director   string
+	MinRating  *float64
+	MaxRating  *float64
+	Sort       []SortField
+	// IncludeDeleted lists soft deleted movies too.
+	IncludeDeleted bool
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
 }
@@ -0,0 +1,13 @@
+package models
+
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Total      int    `json:"total"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Session struct {
+	Id              int
+	UserId          int
+	TokenHash       string
+	AccessTokenId   string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	RevokedAt       *time.Time
+}
@@ -1,8 +1,24 @@
 package models
 
+import "time"
+
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
 type User struct {
-	Id           int
-	Name         string
-	Email        string
-	PasswordHash string
+	Id              int
+	Name            string
+	Email           string
+	PasswordHash    string
+	Role            string
+	EmailVerifiedAt *time.Time
+	Version         int
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleViewer
 }
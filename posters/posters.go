@@ -0,0 +1,146 @@
+package posters
+
+import (
+	"bytes"
+	"filmservice/apperrors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = apperrors.InvalidField("poster", "must be a JPEG, PNG or WebP image")
+	ErrTooLarge          = apperrors.TooLarge("Poster is too large")
+)
+
+// Widths are the thumbnail widths generated for every poster, in ascending order.
+var Widths = []int{160, 320, 640}
+
+// maxPixels guards against images whose small files decode into huge bitmaps.
+const maxPixels = 50_000_000
+
+const jpegQuality = 90
+
+// Image is an encoded poster or one of its thumbnails.
+type Image struct {
+	Width       int
+	Content     []byte
+	ContentType string
+}
+
+// Poster is a validated upload, re-encoded so that metadata such as EXIF is dropped.
+type Poster struct {
+	Ext        string
+	Original   Image
+	Thumbnails []Image
+}
+
+// Process reads at most maxBytes of an upload, checks its magic bytes, and re-encodes it
+// together with a thumbnail for every width in Widths that is smaller than the image.
+// WebP has no encoder in the standard library, so WebP uploads are stored as PNG.
+func Process(r io.Reader, maxBytes int64) (Poster, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return Poster{}, err
+	}
+	if int64(len(content)) > maxBytes {
+		return Poster{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(content)
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
+		return Poster{}, ErrUnsupportedFormat
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return Poster{}, ErrUnsupportedFormat
+	}
+	if config.Width*config.Height > maxPixels {
+		return Poster{}, ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return Poster{}, ErrUnsupportedFormat
+	}
+
+	encode := encodePNG
+	poster := Poster{Ext: ".png"}
+	if contentType == "image/jpeg" {
+		encode = encodeJPEG
+		poster.Ext = ".jpg"
+	}
+
+	poster.Original, err = encode(decoded)
+	if err != nil {
+		return Poster{}, err
+	}
+
+	bounds := decoded.Bounds()
+	for _, width := range Widths {
+		if width >= bounds.Dx() {
+			break
+		}
+
+		height := max(1, bounds.Dy()*width/bounds.Dx())
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), decoded, bounds, draw.Src, nil)
+
+		thumbnail, err := encode(scaled)
+		if err != nil {
+			return Poster{}, err
+		}
+
+		poster.Thumbnails = append(poster.Thumbnails, thumbnail)
+	}
+
+	return poster, nil
+}
+
+// VariantKey returns the storage key of the thumbnail of the given width,
+// e.g. 3f2c….jpg becomes 3f2c…_w320.jpg.
+func VariantKey(key string, width int) string {
+	ext := path.Ext(key)
+	return fmt.Sprintf("%s_w%d%s", strings.TrimSuffix(key, ext), width, ext)
+}
+
+// VariantWidth returns the smallest thumbnail width that is at least the requested one,
+// or 0 when only the original is large enough.
+func VariantWidth(requested int) int {
+	for _, width := range Widths {
+		if width >= requested {
+			return width
+		}
+	}
+
+	return 0
+}
+
+func encodeJPEG(img image.Image) (Image, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{Width: img.Bounds().Dx(), Content: buf.Bytes(), ContentType: "image/jpeg"}, nil
+}
+
+func encodePNG(img image.Image) (Image, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{Width: img.Bounds().Dx(), Content: buf.Bytes(), ContentType: "image/png"}, nil
+}
@@ -0,0 +1,181 @@
+package posters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+)
+
+func encodedImage(t *testing.T, format string, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 200, A: 255})
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		width           int
+		ext             string
+		contentType     string
+		thumbnailWidths []int
+	}{
+		{name: "small jpeg has no thumbnails", format: "jpeg", width: 100, ext: ".jpg", contentType: "image/jpeg"},
+		{name: "jpeg", format: "jpeg", width: 500, ext: ".jpg", contentType: "image/jpeg", thumbnailWidths: []int{160, 320}},
+		{name: "png", format: "png", width: 800, ext: ".png", contentType: "image/png", thumbnailWidths: []int{160, 320, 640}},
+		{name: "width equal to a thumbnail", format: "png", width: 320, ext: ".png", contentType: "image/png", thumbnailWidths: []int{160}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := encodedImage(t, tt.format, tt.width, tt.width*3/2)
+
+			poster, err := Process(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if poster.Ext != tt.ext || poster.Original.ContentType != tt.contentType || poster.Original.Width != tt.width {
+				t.Errorf("original is %s %s %d wide, want %s %s %d", poster.Ext, poster.Original.ContentType,
+					poster.Original.Width, tt.ext, tt.contentType, tt.width)
+			}
+
+			widths := make([]int, 0)
+			for _, thumbnail := range poster.Thumbnails {
+				widths = append(widths, thumbnail.Width)
+				if thumbnail.ContentType != tt.contentType {
+					t.Errorf("thumbnail %d is %s, want %s", thumbnail.Width, thumbnail.ContentType, tt.contentType)
+				}
+
+				config, _, err := image.DecodeConfig(bytes.NewReader(thumbnail.Content))
+				if err != nil || config.Width != thumbnail.Width {
+					t.Errorf("thumbnail %d does not decode to its width: %v", thumbnail.Width, err)
+				}
+			}
+			if !slices.Equal(widths, tt.thumbnailWidths) {
+				t.Errorf("thumbnail widths %v, want %v", widths, tt.thumbnailWidths)
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	png := encodedImage(t, "png", 10, 10)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		maxBytes int64
+		want     error
+	}{
+		{name: "gif", content: encodedImage(t, "gif", 10, 10), maxBytes: 1 << 20, want: ErrUnsupportedFormat},
+		{name: "text", content: []byte("hello, world"), maxBytes: 1 << 20, want: ErrUnsupportedFormat},
+		{name: "html named like an image", content: []byte("<html><body>x</body></html>"), maxBytes: 1 << 20, want: ErrUnsupportedFormat},
+		{name: "truncated png", content: png[:len(png)/2], maxBytes: 1 << 20, want: ErrUnsupportedFormat},
+		{name: "over the size limit", content: png, maxBytes: int64(len(png) - 1), want: ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process(bytes.NewReader(tt.content), tt.maxBytes)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessRejectsDecompressionBombs(t *testing.T) {
+	// A PNG header claiming a huge size is rejected before its pixels are decoded.
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := buf.Bytes()
+	// IHDR follows the 8 byte signature: length, type, then width and height, and the CRC of type and data.
+	binary.BigEndian.PutUint32(content[16:20], 1<<14)
+	binary.BigEndian.PutUint32(content[20:24], 1<<14)
+	binary.BigEndian.PutUint32(content[29:33], crc32.ChecksumIEEE(content[12:29]))
+
+	_, err = Process(bytes.NewReader(content), 1<<20)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestVariantKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		width int
+		want  string
+	}{
+		{key: "3f2c.jpg", width: 320, want: "3f2c_w320.jpg"},
+		{key: "3f2c.png", width: 160, want: "3f2c_w160.png"},
+		{key: "legacy", width: 640, want: "legacy_w640"},
+	}
+
+	for _, tt := range tests {
+		if got := VariantKey(tt.key, tt.width); got != tt.want {
+			t.Errorf("VariantKey(%q, %d) = %q, want %q", tt.key, tt.width, got, tt.want)
+		}
+		if got := OriginalKey(tt.want); got != tt.key {
+			t.Errorf("OriginalKey(%q) = %q, want %q", tt.want, got, tt.key)
+		}
+	}
+
+	if got := OriginalKey("poster_wide.jpg"); got != "poster_wide.jpg" {
+		t.Errorf("OriginalKey changed a key that is not a thumbnail: %q", got)
+	}
+}
+
+func TestVariantWidth(t *testing.T) {
+	tests := []struct {
+		requested int
+		want      int
+	}{
+		{requested: 1, want: 160},
+		{requested: 160, want: 160},
+		{requested: 161, want: 320},
+		{requested: 640, want: 640},
+		{requested: 641, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := VariantWidth(tt.requested); got != tt.want {
+			t.Errorf("VariantWidth(%d) = %d, want %d", tt.requested, got, tt.want)
+		}
+	}
+
+	if !slices.IsSorted(Widths) {
+		t.Errorf("Widths %v must be ascending, VariantWidth and Process rely on it", Widths)
+	}
+}
@@ -0,0 +1,103 @@
+package posters
+
+import (
+	"context"
+	"errors"
+	"filmservice/repositories"
+	"filmservice/storage"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var variantSuffix = regexp.MustCompile(`_w[0-9]+(\.[^.]*)?$`)
+
+// OriginalKey returns the key of the poster a thumbnail key belongs to; other keys are returned unchanged.
+func OriginalKey(key string) string {
+	return variantSuffix.ReplaceAllString(key, "$1")
+}
+
+// Delete removes a poster together with its thumbnails.
+func Delete(c context.Context, store storage.BlobStore, key string) error {
+	errs := []error{store.Delete(c, key)}
+	for _, width := range Widths {
+		errs = append(errs, store.Delete(c, VariantKey(key, width)))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Sweeper deletes stored posters and thumbnails that no movie references anymore.
+type Sweeper struct {
+	store  storage.BlobStore
+	movies *repositories.MoviesRepository
+	logger *zap.Logger
+}
+
+func NewSweeper(store storage.BlobStore, movies *repositories.MoviesRepository, logger *zap.Logger) *Sweeper {
+	return &Sweeper{
+		store:  store,
+		movies: movies,
+		logger: logger,
+	}
+}
+
+// Sweep finds unreferenced objects older than the grace period and deletes them unless dryRun is set.
+// The grace period protects posters that were uploaded but whose movie has not been saved yet.
+// It returns the orphans it found.
+func (s *Sweeper) Sweep(c context.Context, grace time.Duration, dryRun bool) ([]storage.ObjectInfo, error) {
+	// Objects are listed before references are read, so a poster saved in between is never seen as an orphan.
+	objects, err := s.store.List(c)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced, err := s.movies.PosterKeys(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-grace)
+	orphans := make([]storage.ObjectInfo, 0)
+
+	for _, object := range objects {
+		if referenced[OriginalKey(object.Key)] || object.ModTime.After(cutoff) {
+			continue
+		}
+
+		orphans = append(orphans, object)
+		if dryRun {
+			continue
+		}
+
+		err = s.store.Delete(c, object.Key)
+		if err != nil {
+			return orphans, err
+		}
+	}
+
+	return orphans, nil
+}
+
+// Run sweeps every interval until the context is cancelled. Failures are logged and retried on the next tick.
+func (s *Sweeper) Run(c context.Context, interval time.Duration, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			orphans, err := s.Sweep(c, grace, false)
+			if err != nil {
+				s.logger.Error("poster garbage collection failed", zap.Error(err))
+				continue
+			}
+			if len(orphans) > 0 {
+				s.logger.Info("orphaned posters deleted", zap.Int("count", len(orphans)))
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package purge
+
+import (
+	"context"
+	"filmservice/posters"
+	"filmservice/repositories"
+	"filmservice/storage"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Result counts the rows removed by a purge.
+type Result struct {
+	Movies int
+	Genres int
+	Users  int
+}
+
+// Purger removes soft deleted movies, genres and users for good once their retention period is over.
+type Purger struct {
+	movies *repositories.MoviesRepository
+	genres *repositories.GenresRepository
+	users  *repositories.UsersRepository
+	store  storage.BlobStore
+	logger *zap.Logger
+}
+
+func NewPurger(
+	movies *repositories.MoviesRepository,
+	genres *repositories.GenresRepository,
+	users *repositories.UsersRepository,
+	store storage.BlobStore,
+	logger *zap.Logger,
+) *Purger {
+	return &Purger{
+		movies: movies,
+		genres: genres,
+		users:  users,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Purge removes the rows deleted longer than the retention period ago, and the posters of the purged movies.
+// Posters that cannot be deleted are only logged, the poster sweeper collects them later.
+func (p *Purger) Purge(c context.Context, retention time.Duration) (Result, error) {
+	cutoff := time.Now().Add(-retention)
+
+	keys, err := p.movies.Purge(c, cutoff)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Movies: len(keys)}
+
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		err = posters.Delete(c, p.store, key)
+		if err != nil {
+			p.logger.Warn("could not delete poster", zap.String("poster", key), zap.Error(err))
+		}
+	}
+
+	result.Genres, err = p.genres.Purge(c, cutoff)
+	if err != nil {
+		return result, err
+	}
+
+	result.Users, err = p.users.Purge(c, cutoff)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Run purges every interval until the context is cancelled. Failures are logged and retried on the next tick.
+func (p *Purger) Run(c context.Context, interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			result, err := p.Purge(c, retention)
+			if err != nil {
+				p.logger.Error("purge of deleted records failed", zap.Error(err))
+				continue
+			}
+			if result.Movies+result.Genres+result.Users > 0 {
+				p.logger.Info("deleted records purged",
+					zap.Int("movies", result.Movies),
+					zap.Int("genres", result.Genres),
+					zap.Int("users", result.Users))
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	auditActionCreate         = "create"
+	auditActionUpdate         = "update"
+	auditActionDelete         = "delete"
+	auditActionRestore        = "restore"
+	auditActionPurge          = "purge"
+	auditActionChangePassword = "change_password"
+	auditActionResetPassword  = "reset_password"
+	auditActionVerifyEmail    = "verify_email"
+)
+
+// auditEntity describes how an audited table is snapshotted. Secrets and derived columns are left out
+// of the snapshot, and so of the audit trail.
+type auditEntity struct {
+	name     string
+	table    string
+	snapshot string
+}
+
+var (
+	movieAudit = auditEntity{
+		name:  "movie",
+		table: "movies",
+		snapshot: `select to_jsonb(m) - 'search_vector' - 'updated_at' || jsonb_build_object('genre_ids', coalesce((
+	select jsonb_agg(mg.genre_id order by mg.genre_id) from movies_genres mg where mg.movie_id = m.id
+), '[]'::jsonb))
+from movies m
+where m.id = $1`,
+	}
+	genreAudit = auditEntity{
+		name:     "genre",
+		table:    "genres",
+		snapshot: "select to_jsonb(g) - 'updated_at' from genres g where g.id = $1",
+	}
+	userAudit = auditEntity{
+		name:     "user",
+		table:    "users",
+		snapshot: "select to_jsonb(u) - 'password_hash' - 'updated_at' from users u where u.id = $1",
+	}
+)
+
+// takeSnapshot returns the row with the given id as JSON, or nil when there is none.
+func (e auditEntity) takeSnapshot(c context.Context, tx pgx.Tx, id int) ([]byte, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	var snapshot []byte
+	err := tx.QueryRow(c, e.snapshot, id).Scan(&snapshot)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	return snapshot, err
+}
+
+// audited runs a mutation of the entity with the given id, 0 for a new one, in a transaction that
+// also records it in the audit trail. mutate returns the id of the entity it changed.
+func audited(c context.Context, db *pgxpool.Pool, entity auditEntity, action string, id int, mutate func(tx pgx.Tx) (int, error)) (int, error) {
+	tx, err := db.Begin(c)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(c)
+
+	id, err = auditedTx(c, tx, entity, action, id, mutate)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(c)
+}
+
+// auditedTx is audited within a transaction of the caller.
+func auditedTx(c context.Context, tx pgx.Tx, entity auditEntity, action string, id int, mutate func(tx pgx.Tx) (int, error)) (int, error) {
+	// The row is locked first, so that the snapshot is still current when the mutation runs.
+	if id != 0 {
+		_, err := tx.Exec(c, fmt.Sprintf("select 1 from %s where id = $1 for update", entity.table), id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	before, err := entity.takeSnapshot(c, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err = mutate(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := entity.takeSnapshot(c, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, recordAudit(c, tx, entity, action, id, before, after)
+}
+
+// recordAudit writes an audit event with the fields that differ between the snapshots. Creations and
+// purges keep the whole snapshot; nothing is written when nothing changed.
+func recordAudit(c context.Context, tx pgx.Tx, entity auditEntity, action string, id int, before []byte, after []byte) error {
+	before, after, err := auditDiff(before, after)
+	if err != nil || before == nil && after == nil {
+		return err
+	}
+
+	actorId, requestId, ip := auditActor(c)
+
+	_, err = tx.Exec(c, `insert into audit_events (actor_id, action, entity, entity_id, "before", "after", request_id, ip)
+values ($1, $2, $3, $4, $5, $6, nullif($7, ''), nullif($8, ''))`,
+		actorId, action, entity.name, id, before, after, requestId, ip)
+
+	return err
+}
+
+func auditDiff(before []byte, after []byte) ([]byte, []byte, error) {
+	if before == nil || after == nil {
+		return before, after, nil
+	}
+
+	var beforeFields, afterFields map[string]json.RawMessage
+	err := json.Unmarshal(before, &beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = json.Unmarshal(after, &afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// jsonb renders equal values identically, so comparing the raw values is enough.
+	for field, value := range beforeFields {
+		if bytes.Equal(value, afterFields[field]) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	if len(beforeFields) == 0 && len(afterFields) == 0 {
+		return nil, nil, nil
+	}
+
+	before, err = json.Marshal(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, err = json.Marshal(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return before, after, nil
+}
+
+// auditActor reads who is making a change from the request context handlers pass down.
+// Unauthenticated requests and background jobs have no actor.
+func auditActor(c context.Context) (*int, string, string) {
+	gc, ok := c.(*gin.Context)
+	if !ok || gc.Request == nil {
+		return nil, "", ""
+	}
+
+	var actorId *int
+	if userId := gc.GetInt("userId"); userId != 0 {
+		actorId = &userId
+	}
+
+	return actorId, gc.GetString("requestId"), gc.ClientIP()
+}
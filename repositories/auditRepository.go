@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"filmservice/models"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(conn *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{db: conn}
+}
+
+// Newest events come first.
+var auditSortKeys = []sortKey{
+	{expr: "id", sqlType: "int8", desc: true},
+}
+
+func (r *AuditRepository) FindAll(c context.Context, filters models.AuditFilters, page models.PageRequest) (models.Page[models.AuditEvent], error) {
+	where := "where true"
+	params := pgx.NamedArgs{}
+
+	if filters.ActorId != nil {
+		where = fmt.Sprintf("%s and actor_id = @actorId", where)
+		params["actorId"] = *filters.ActorId
+	}
+
+	if filters.Entity != "" {
+		where = fmt.Sprintf("%s and entity = @entity", where)
+		params["entity"] = filters.Entity
+	}
+
+	if filters.EntityId != nil {
+		where = fmt.Sprintf("%s and entity_id = @entityId", where)
+		params["entityId"] = *filters.EntityId
+	}
+
+	if filters.Action != "" {
+		where = fmt.Sprintf("%s and action = @action", where)
+		params["action"] = filters.Action
+	}
+
+	if filters.From != nil {
+		where = fmt.Sprintf("%s and occurred_at >= @from", where)
+		params["from"] = *filters.From
+	}
+
+	if filters.To != nil {
+		where = fmt.Sprintf("%s and occurred_at < @to", where)
+		params["to"] = *filters.To
+	}
+
+	var total int
+	err := r.db.QueryRow(c, fmt.Sprintf("select count(*) from audit_events %s", where), params).Scan(&total)
+	if err != nil {
+		return models.Page[models.AuditEvent]{}, err
+	}
+
+	condition, limit, err := pageClause(auditSortKeys, page, params)
+	if err != nil {
+		return models.Page[models.AuditEvent]{}, err
+	}
+
+	sql := fmt.Sprintf(`select id, occurred_at, actor_id, action, entity, entity_id, "before", "after", coalesce(request_id, ''), coalesce(ip, ''), %s
+from audit_events
+%s
+	and %s
+order by %s
+%s`, sortValuesSelect(auditSortKeys), where, condition, orderByClause(auditSortKeys), limit)
+
+	rows, err := r.db.Query(c, sql, params)
+	if err != nil {
+		return models.Page[models.AuditEvent]{}, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	sortValues := make([][]string, 0)
+
+	for rows.Next() {
+		var event models.AuditEvent
+		var id string
+		err := rows.Scan(&event.Id, &event.OccurredAt, &event.ActorId, &event.Action, &event.Entity, &event.EntityId,
+			&event.Before, &event.After, &event.RequestId, &event.Ip, &id)
+		if err != nil {
+			return models.Page[models.AuditEvent]{}, err
+		}
+
+		events = append(events, event)
+		sortValues = append(sortValues, []string{id})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return models.Page[models.AuditEvent]{}, err
+	}
+
+	return buildPage(auditSortKeys, page, events, sortValues, total), nil
+}
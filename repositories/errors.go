@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+	"filmservice/apperrors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrMovieNotFound = apperrors.NotFound("Movie not found")
+	ErrGenreNotFound = apperrors.NotFound("Genre not found")
+	ErrUserNotFound  = apperrors.NotFound("User not found")
+)
+
+// notFound turns pgx.ErrNoRows into the given not found error. The result still wraps
+// pgx.ErrNoRows, so callers checking for it keep working.
+func notFound(err error, notFoundErr *apperrors.Error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFoundErr.Wrap(err)
+	}
+
+	return err
+}
+
+// referencedNotFound turns a foreign key violation, raised when a row points at one that
+// does not exist, into the given not found error.
+func referencedNotFound(err error, notFoundErr *apperrors.Error) error {
+	if isForeignKeyViolation(err) {
+		return notFoundErr.Wrap(err)
+	}
+
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -2,8 +2,12 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"filmservice/models"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,40 +23,71 @@ func NewGenresRepository(conn *pgxpool.Pool) *GenresRepository {
 
 func (r *GenresRepository) FindById(c context.Context, id int) (models.Genre, error) {
 	var genre models.Genre
-	row := r.db.QueryRow(c, "select id, title from genres where id = $1", id)
+	row := r.db.QueryRow(c, "select id, title, version, updated_at from genres where id = $1 and deleted_at is null", id)
 
-	err := row.Scan(&genre.Id, &genre.Title)
+	err := row.Scan(&genre.Id, &genre.Title, &genre.Version, &genre.UpdatedAt)
 	if err != nil {
-		return models.Genre{}, err
+		return models.Genre{}, notFound(err, ErrGenreNotFound)
 	}
 
 	return genre, nil
 }
 
-func (r *GenresRepository) FindAll(c context.Context) ([]models.Genre, error) {
-	rows, err := r.db.Query(c, "select id, title from genres")
-	defer rows.Close()
+var genreSortKeys = []sortKey{
+	{expr: "id", sqlType: "int4"},
+}
+
+// FindAll lists the genres, soft deleted ones only when includeDeleted is set.
+func (r *GenresRepository) FindAll(c context.Context, page models.PageRequest, includeDeleted bool) (models.Page[models.Genre], error) {
+	filter := deletedFilter("genres", includeDeleted)
+
+	var total int
+	err := r.db.QueryRow(c, fmt.Sprintf("select count(*) from genres where %s", filter)).Scan(&total)
 	if err != nil {
-		return nil, err
+		return models.Page[models.Genre]{}, err
 	}
 
+	params := pgx.NamedArgs{}
+	condition, limit, err := pageClause(genreSortKeys, page, params)
+	if err != nil {
+		return models.Page[models.Genre]{}, err
+	}
+
+	sql := fmt.Sprintf("select id, title, version, updated_at, deleted_at, %s from genres where %s and %s order by %s %s",
+		sortValuesSelect(genreSortKeys), filter, condition, orderByClause(genreSortKeys), limit)
+
+	rows, err := r.db.Query(c, sql, params)
+	if err != nil {
+		return models.Page[models.Genre]{}, err
+	}
+	defer rows.Close()
+
 	genres := make([]models.Genre, 0)
+	sortValues := make([][]string, 0)
 
 	for rows.Next() {
 		var genre models.Genre
-		err = rows.Scan(&genre.Id, &genre.Title)
+		var id string
+		err = rows.Scan(&genre.Id, &genre.Title, &genre.Version, &genre.UpdatedAt, &genre.DeletedAt, &id)
 		if err != nil {
-			return nil, err
+			return models.Page[models.Genre]{}, err
 		}
 
 		genres = append(genres, genre)
+		sortValues = append(sortValues, []string{id})
 	}
 
-	return genres, nil
+	err = rows.Err()
+	if err != nil {
+		return models.Page[models.Genre]{}, err
+	}
+
+	return buildPage(genreSortKeys, page, genres, sortValues, total), nil
 }
 
+// FindAllByIds returns the active genres among the given ids.
 func (r *GenresRepository) FindAllByIds(c context.Context, ids []int) ([]models.Genre, error) {
-	rows, err := r.db.Query(c, "select id, title from genres where id = any($1)", ids)
+	rows, err := r.db.Query(c, "select id, title from genres where id = any($1) and deleted_at is null", ids)
 	defer rows.Close()
 	if err != nil {
 		return nil, err
@@ -74,29 +109,89 @@ func (r *GenresRepository) FindAllByIds(c context.Context, ids []int) ([]models.
 }
 
 func (r *GenresRepository) Create(c context.Context, genre models.Genre) (int, error) {
-	var id int
-	row := r.db.QueryRow(c, "insert into genres (title) values ($1) returning id", genre.Title)
-	err := row.Scan(&id)
+	return audited(c, r.db, genreAudit, auditActionCreate, 0, func(tx pgx.Tx) (int, error) {
+		var id int
+		row := tx.QueryRow(c, "insert into genres (title) values ($1) returning id", genre.Title)
+		err := row.Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+		return id, nil
+	})
+}
+
+// Update changes the genre unless its version differs from genre.Version, and returns the new version.
+func (r *GenresRepository) Update(c context.Context, id int, genre models.Genre) (int, error) {
+	var version int
+	_, err := audited(c, r.db, genreAudit, auditActionUpdate, id, func(tx pgx.Tx) (int, error) {
+		err := tx.QueryRow(c, `update genres set title = $1, version = version + 1, updated_at = now()
+where id = $2 and deleted_at is null and ($3 = 0 or version = $3) returning version`, genre.Title, id, genre.Version).Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, notFound(missingOrModified(c, tx, "genres", id), ErrGenreNotFound)
+		}
+
+		return id, err
+	})
 	if err != nil {
 		return 0, err
 	}
-	return id, nil
+
+	return version, nil
+}
+
+// Delete marks the genre as deleted unless its version differs from the expected one. Movies keep
+// referencing it until Purge, but it is no longer listed with them.
+func (r *GenresRepository) Delete(c context.Context, id int, version int) error {
+	_, err := audited(c, r.db, genreAudit, auditActionDelete, id, func(tx pgx.Tx) (int, error) {
+		return id, softDelete(c, tx, "genres", id, version)
+	})
+
+	return err
 }
 
-func (r *GenresRepository) Update(c context.Context, id int, genre models.Genre) error {
-	_, err := r.db.Exec(c, "update genres set title = $1 where id = $2", genre.Title, id)
+// Restore brings back a deleted genre and returns its version.
+func (r *GenresRepository) Restore(c context.Context, id int) (int, error) {
+	var version int
+	_, err := audited(c, r.db, genreAudit, auditActionRestore, id, func(tx pgx.Tx) (int, error) {
+		var err error
+		version, err = restore(c, tx, "genres", id)
+		return id, err
+	})
 	if err != nil {
-		return err
+		return 0, notFound(err, ErrGenreNotFound)
 	}
 
-	return nil
+	return version, nil
 }
 
-func (r *GenresRepository) Delete(c context.Context, id int) error {
-	_, err := r.db.Exec(c, "delete from genres where id = $1", id)
+// Purge removes the genres deleted before the cutoff from their movies and then for good,
+// and returns how many were removed.
+func (r *GenresRepository) Purge(c context.Context, cutoff time.Time) (int, error) {
+	tx, err := r.db.Begin(c)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback(c)
+
+	ids, err := lockDeletedBefore(c, tx, "genres", cutoff)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		_, err = auditedTx(c, tx, genreAudit, auditActionPurge, id, func(tx pgx.Tx) (int, error) {
+			_, err := tx.Exec(c, "delete from movies_genres where genre_id = $1", id)
+			if err != nil {
+				return 0, err
+			}
+
+			_, err = tx.Exec(c, "delete from genres where id = $1", id)
+			return id, err
+		})
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return len(ids), tx.Commit(c)
 }
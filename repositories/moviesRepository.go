@@ -2,16 +2,21 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"filmservice/apperrors"
 	logger2 "filmservice/logger"
 	"filmservice/models"
 	"fmt"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+var ErrInvalidSort = apperrors.Validation("Invalid sort")
+
 type MoviesRepository struct {
 	db *pgxpool.Pool
 }
@@ -20,137 +25,301 @@ func NewMoviesRepository(conn *pgxpool.Pool) *MoviesRepository {
 	return &MoviesRepository{db: conn}
 }
 
-func (r *MoviesRepository) FindById(c context.Context, id int) (models.Movie, error) {
-	sql := `select
+// movieGenresSelect aggregates the active genres of the movie with the given alias into a JSON array,
+// which is empty rather than null for movies without genres.
+func movieGenresSelect(alias string) string {
+	return fmt.Sprintf(`coalesce((
+	select json_agg(json_build_object('Id', g.id, 'Title', g.title, 'Version', g.version, 'UpdatedAt', g.updated_at) order by g.id)
+	from movies_genres mg
+	join genres g on
+		g.id = mg.genre_id
+		and g.deleted_at is null
+	where mg.movie_id = %s.id
+), '[]')`, alias)
+}
+
+func (r *MoviesRepository) FindById(c context.Context, id int, userId int) (models.Movie, error) {
+	sql := fmt.Sprintf(`select
 	m.id,
 	m.title ,
 	m.description ,
 	m.release_year ,
 	m.director ,
-	m.rating ,
-	m.is_watched ,
+	coalesce(ums.rating, 0),
+	coalesce(ums.is_watched, false),
+	coalesce(rs.average_rating, 0),
+	rs.ratings_count,
 	m.trailer_url ,
 	m.poster_url,
-	g.id,
-	g.title
+	m.version,
+	m.updated_at,
+	%s
 from movies m
-join movies_genres mg on
-	mg.movie_id = m.id
-join genres g on
-	mg.genre_id = g.id
+left join user_movie_state ums on
+	ums.movie_id = m.id
+	and ums.user_id = $2
+left join lateral (
+	select avg(s.rating)::float8 as average_rating, count(s.rating) as ratings_count
+	from user_movie_state s
+	where s.movie_id = m.id
+) rs on true
 	where m.id = $1
-	`
-
-	logger := logger2.GetLogger()
-
-	rows, err := r.db.Query(c, sql, id)
+		and m.deleted_at is null
+	`, movieGenresSelect("m"))
+
+	var m models.Movie
+	err := r.db.QueryRow(c, sql, id, userId).Scan(
+		&m.Id,
+		&m.Title,
+		&m.Description,
+		&m.ReleaseYear,
+		&m.Director,
+		&m.Rating,
+		&m.IsWatched,
+		&m.AverageRating,
+		&m.RatingsCount,
+		&m.TrailerUrl,
+		&m.PosterUrl,
+		&m.Version,
+		&m.UpdatedAt,
+		&m.Genres,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Movie{}, ErrMovieNotFound.Wrap(err)
+	}
 	if err != nil {
-		logger.Error("could not query database", zap.String("db_msg", err.Error()))
+		logger2.FromContext(c).Error("could not query database", zap.String("db_msg", err.Error()))
 		return models.Movie{}, err
 	}
 
-	defer rows.Close()
+	return m, nil
+}
 
-	var movie *models.Movie
+var movieSortKeys = map[string]sortKey{
+	"id":           {expr: "m.id", sqlType: "int4"},
+	"title":        {expr: "coalesce(m.title, '')", sqlType: "text"},
+	"release_year": {expr: "coalesce(m.release_year, 0)", sqlType: "int4"},
+	"director":     {expr: "coalesce(m.director, '')", sqlType: "text"},
+	"rating":       {expr: "coalesce(rs.average_rating, 0)", sqlType: "float8"},
+	"is_watched":   {expr: "coalesce(ums.is_watched, false)", sqlType: "bool"},
+}
 
-	for rows.Next() {
-		var m models.Movie
-		var g models.Genre
+// movieRelevanceKey ranks full-text matches and adds the title similarity, so that
+// typo-tolerant matches are ordered too. It is only available while searching.
+var movieRelevanceKey = sortKey{
+	expr:    "(ts_rank(coalesce(m.search_vector, ''), websearch_to_tsquery('simple', @s)) + word_similarity(@s, coalesce(m.title, '')))::float8",
+	sqlType: "float8",
+}
 
-		err := rows.Scan(
-			&m.Id,
-			&m.Title,
-			&m.Description,
-			&m.ReleaseYear,
-			&m.Director,
-			&m.Rating,
-			&m.IsWatched,
-			&m.TrailerUrl,
-			&m.PosterUrl,
-			&g.Id,
-			&g.Title,
-		)
-		if err != nil {
-			logger.Error("could not scan query row", zap.String("db_msg", err.Error()))
-			return models.Movie{}, err
-		}
+// movieSort returns the keyset ordering for the requested sort fields; the id always breaks ties.
+// Searches without explicit sort fields are ordered by relevance.
+func movieSort(fields []models.SortField, searching bool) ([]sortKey, error) {
+	if searching && len(fields) == 0 {
+		fields = []models.SortField{{Field: "relevance", Desc: true}}
+	}
 
-		if movie != nil {
-			m = *movie
+	keys := make([]sortKey, 0, len(fields)+1)
+	hasId := false
+
+	for _, field := range fields {
+		key, ok := movieSortKeys[field.Field]
+		if field.Field == "relevance" && searching {
+			key, ok = movieRelevanceKey, true
+		}
+		if !ok {
+			return nil, apperrors.Validation(ErrInvalidSort.Message, apperrors.Field("sort", fmt.Sprintf("cannot sort by %q", field.Field)))
 		}
 
-		m.Genres = append(m.Genres, g)
-		movie = &m
+		key.desc = field.Desc
+		keys = append(keys, key)
+
+		if field.Field == "id" {
+			hasId = true
+		}
 	}
 
-	err = rows.Err()
-	if err != nil {
-		logger.Error(err.Error())
-		return models.Movie{}, err
+	if !hasId {
+		keys = append(keys, movieSortKeys["id"])
 	}
 
-	return *movie, nil
+	return keys, nil
 }
 
-func (r *MoviesRepository) FindAll(c context.Context, filters models.MovieFilters) ([]models.Movie, error) {
-	sql := `select
-	m.id,
-	m.title ,
-	m.description ,
-	m.release_year ,
-	m.director ,
-	m.rating ,
-	m.is_watched ,
-	m.trailer_url ,
-	m.poster_url,
-	g.id,
-	g.title
-from movies m
-join movies_genres mg on
-	mg.movie_id = m.id
-join genres g on
-	mg.genre_id = g.id
-where 1=1
+func (r *MoviesRepository) FindAll(
+	c context.Context,
+	userId int,
+	filters models.MovieFilters,
+	page models.PageRequest,
+) (models.Page[models.Movie], error) {
+	keys, err := movieSort(filters.Sort, filters.SearchTerm != "")
+	if err != nil {
+		return models.Page[models.Movie]{}, err
+	}
+
+	from := `from movies m
+left join user_movie_state ums on
+	ums.movie_id = m.id
+	and ums.user_id = @userId
+left join lateral (
+	select avg(s.rating)::float8 as average_rating, count(s.rating) as ratings_count
+	from user_movie_state s
+	where s.movie_id = m.id
+) rs on true
+where %s
 	`
 
-	params := pgx.NamedArgs{}
+	from = fmt.Sprintf(from, deletedFilter("m", filters.IncludeDeleted))
+
+	params := pgx.NamedArgs{
+		"userId": userId,
+	}
 
+	// Titles within the pg_trgm word similarity threshold match as well, so small typos are forgiven.
+	highlights := "null::text, null::text, null::text"
 	if filters.SearchTerm != "" {
-		sql = fmt.Sprintf("%s and m.title ilike @s", sql)
-		params["s"] = fmt.Sprintf("%%%s%%", filters.SearchTerm)
+		from = fmt.Sprintf("%s and (m.search_vector @@ websearch_to_tsquery('simple', @s) or @s <%% m.title)", from)
+		params["s"] = filters.SearchTerm
+
+		// Highlights are HTML, so the stored text is escaped and only the <mark> tags are markup.
+		highlights = fmt.Sprintf(`ts_headline('simple', %s, websearch_to_tsquery('simple', @s), @headlineOptions),
+	ts_headline('simple', %s, websearch_to_tsquery('simple', @s), @headlineOptions),
+	ts_headline('simple', %s, websearch_to_tsquery('simple', @s), @descriptionHeadlineOptions)`,
+			escapeHtml("p.title"), escapeHtml("p.director"), escapeHtml("p.description"))
+		params["headlineOptions"] = "StartSel=<mark>, StopSel=</mark>"
+		params["descriptionHeadlineOptions"] = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2"
+	}
+
+	if filters.Director != "" {
+		from = fmt.Sprintf("%s and m.director ilike @director", from)
+		params["director"] = fmt.Sprintf("%%%s%%", escapeLike(filters.Director))
+	}
+
+	if len(filters.GenreIds) > 0 {
+		params["genreIds"] = filters.GenreIds
+
+		// Deleted genres are not listed with movies, so they do not match either.
+		if filters.GenreMode == models.GenreModeAll {
+			from = fmt.Sprintf(`%s and (
+	select count(distinct fg.genre_id) from movies_genres fg
+	join genres g on g.id = fg.genre_id and g.deleted_at is null
+	where fg.movie_id = m.id and fg.genre_id = any(@genreIds)
+) = cardinality(@genreIds::int4[])`, from)
+		} else {
+			from = fmt.Sprintf(`%s and exists (
+	select 1 from movies_genres fg
+	join genres g on g.id = fg.genre_id and g.deleted_at is null
+	where fg.movie_id = m.id and fg.genre_id = any(@genreIds)
+)`, from)
+		}
 	}
 
-	if filters.GenreId != "" {
-		sql = fmt.Sprintf("%s and g.id = @genreId", sql)
-		params["genreId"] = filters.GenreId
+	if filters.IsWatched != nil {
+		from = fmt.Sprintf("%s and coalesce(ums.is_watched, false) = @isWatched", from)
+		params["isWatched"] = *filters.IsWatched
 	}
 
-	if filters.IsWatched != "" {
-		isWatched, _ := strconv.ParseBool(filters.IsWatched)
+	if filters.YearFrom != nil {
+		from = fmt.Sprintf("%s and m.release_year >= @yearFrom", from)
+		params["yearFrom"] = *filters.YearFrom
+	}
+
+	if filters.YearTo != nil {
+		from = fmt.Sprintf("%s and m.release_year <= @yearTo", from)
+		params["yearTo"] = *filters.YearTo
+	}
+
+	// Rating bounds apply to the average, so movies nobody rated yet never match them.
+	if filters.MinRating != nil {
+		from = fmt.Sprintf("%s and rs.average_rating >= @minRating", from)
+		params["minRating"] = *filters.MinRating
+	}
 
-		sql = fmt.Sprintf("%s and m.is_watched = @isWatched", sql)
-		params["isWatched"] = isWatched
+	if filters.MaxRating != nil {
+		from = fmt.Sprintf("%s and rs.average_rating <= @maxRating", from)
+		params["maxRating"] = *filters.MaxRating
 	}
 
-	if filters.Sort != "" {
-		identifier := pgx.Identifier{filters.Sort}
-		sql = fmt.Sprintf("%s order by m.%s", sql, identifier.Sanitize())
+	var total int
+	err = r.db.QueryRow(c, fmt.Sprintf("select count(*) %s", from), params).Scan(&total)
+	if err != nil {
+		return models.Page[models.Movie]{}, err
 	}
 
+	condition, limit, err := pageClause(keys, page, params)
+	if err != nil {
+		return models.Page[models.Movie]{}, err
+	}
+
+	// The page is cut from movies first, so that genres and highlights are only built for its rows.
+	sql := fmt.Sprintf(`with page as (
+	select
+		m.id,
+		m.title,
+		m.description,
+		m.release_year,
+		m.director,
+		coalesce(ums.rating, 0) as rating,
+		coalesce(ums.is_watched, false) as is_watched,
+		coalesce(rs.average_rating, 0) as average_rating,
+		rs.ratings_count,
+		m.trailer_url,
+		m.poster_url,
+		m.version,
+		m.updated_at,
+		m.deleted_at,
+		%s,
+		row_number() over (order by %s) as position
+	%s
+		and %s
+	order by %s
+	%s
+)
+select
+	p.id,
+	p.title,
+	p.description,
+	p.release_year,
+	p.director,
+	p.rating,
+	p.is_watched,
+	p.average_rating,
+	p.ratings_count,
+	p.trailer_url,
+	p.poster_url,
+	p.version,
+	p.updated_at,
+	p.deleted_at,
+	%s,
+	%s,
+	%s
+from page p
+order by p.position`,
+		sortValuesSelect(keys),
+		orderByClause(keys),
+		from,
+		condition,
+		orderByClause(keys),
+		limit,
+		highlights,
+		movieGenresSelect("p"),
+		sortValuesColumns("p", len(keys)),
+	)
+
 	rows, err := r.db.Query(c, sql, params)
 	if err != nil {
-		return nil, err
+		return models.Page[models.Movie]{}, err
 	}
 	defer rows.Close()
 
-	movies := make([]*models.Movie, 0)
-	moviesMap := make(map[int]*models.Movie)
+	movies := make([]models.Movie, 0)
+	sortValues := make([][]string, 0)
 
 	for rows.Next() {
 		var m models.Movie
-		var g models.Genre
+		var highlight [3]*string
+		values := make([]string, len(keys))
 
-		err := rows.Scan(
+		dest := []any{
 			&m.Id,
 			&m.Title,
 			&m.Description,
@@ -158,136 +327,315 @@ where 1=1
 			&m.Director,
 			&m.Rating,
 			&m.IsWatched,
+			&m.AverageRating,
+			&m.RatingsCount,
 			&m.TrailerUrl,
 			&m.PosterUrl,
-			&g.Id,
-			&g.Title,
-		)
+			&m.Version,
+			&m.UpdatedAt,
+			&m.DeletedAt,
+			&highlight[0],
+			&highlight[1],
+			&highlight[2],
+			&m.Genres,
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
-			return nil, err
+			return models.Page[models.Movie]{}, err
 		}
 
-		if _, exists := moviesMap[m.Id]; !exists {
-			moviesMap[m.Id] = &m
-			movies = append(movies, &m)
+		if highlight[0] != nil {
+			m.Highlight = &models.MovieHighlight{
+				Title:       *highlight[0],
+				Director:    *highlight[1],
+				Description: *highlight[2],
+			}
 		}
 
-		moviesMap[m.Id].Genres = append(moviesMap[m.Id].Genres, g)
+		movies = append(movies, m)
+		sortValues = append(sortValues, values)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, err
+		return models.Page[models.Movie]{}, err
 	}
 
-	concreteMovies := make([]models.Movie, 0, len(movies))
-	for _, v := range movies {
-		concreteMovies = append(concreteMovies, *v)
-	}
-
-	return concreteMovies, nil
+	return buildPage(keys, page, movies, sortValues, total), nil
 }
 
 func (r *MoviesRepository) Create(c context.Context, movie models.Movie) (int, error) {
-	tx, err := r.db.Begin(c)
-	if err != nil {
-		return 0, err
-	}
-	defer tx.Rollback(c)
+	return audited(c, r.db, movieAudit, auditActionCreate, 0, func(tx pgx.Tx) (int, error) {
+		var id int
+		row := tx.QueryRow(c, `insert into movies (title, description, release_year, director, trailer_url, poster_url) values ($1, $2, $3, $4, $5, $6) returning id`, movie.Title, movie.Description, movie.ReleaseYear, movie.Director, movie.TrailerUrl, movie.PosterUrl)
+
+		err := row.Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, genre := range movie.Genres {
+			_, err := tx.Exec(c, "insert into movies_genres(movie_id, genre_id) values ($1, $2)", id, genre.Id)
+			if err != nil {
+				return 0, err
+			}
+		}
 
-	var id int
-	row := tx.QueryRow(c, `insert into movies (title, description, release_year, director, trailer_url, poster_url) values ($1, $2, $3, $4, $5, $6) returning id`, movie.Title, movie.Description, movie.ReleaseYear, movie.Director, movie.TrailerUrl, movie.PosterUrl)
+		return id, nil
+	})
+}
+
+// Update replaces the movie and returns the poster it referenced before and the new version.
+// The movie's Version is the one the client expects, see AnyVersion.
+func (r *MoviesRepository) Update(c context.Context, id int, updatedMovie models.Movie) (string, int, error) {
+	var previousPoster *string
+	var version int
+
+	_, err := audited(c, r.db, movieAudit, auditActionUpdate, id, func(tx pgx.Tx) (int, error) {
+		err := tx.QueryRow(c, "select poster_url, version from movies where id = $1 and deleted_at is null for update", id).Scan(&previousPoster, &version)
+		if err != nil {
+			return 0, notFound(err, ErrMovieNotFound)
+		}
+
+		err = checkVersion(updatedMovie.Version, version)
+		if err != nil {
+			return 0, err
+		}
+
+		// An empty poster keeps the current one.
+		err = tx.QueryRow(c, "update movies set title = $1, description = $2, release_year =$3, director = $4, trailer_url = $5, poster_url = coalesce(nullif($6, ''), poster_url), version = version + 1, updated_at = now()  where id = $7 returning version", updatedMovie.Title, updatedMovie.Description, updatedMovie.ReleaseYear, updatedMovie.Director, updatedMovie.TrailerUrl, updatedMovie.PosterUrl, id).Scan(&version)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(c, "delete from movies_genres where movie_id = $1", id)
+		if err != nil {
+			return 0, err
+		}
 
-	err = row.Scan(&id)
+		for _, genre := range updatedMovie.Genres {
+			_, err := tx.Exec(c, "insert into movies_genres(movie_id, genre_id) values ($1, $2)", id, genre.Id)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return id, nil
+	})
 	if err != nil {
-		return 0, err
+		return "", 0, err
+	}
+
+	if previousPoster == nil {
+		return "", version, nil
 	}
 
-	for _, genre := range movie.Genres {
-		_, err := tx.Exec(c, "insert into movies_genres(movie_id, genre_id) values ($1, $2)", id, genre.Id)
+	return *previousPoster, version, nil
+}
+
+// Patch changes the fields set in the patch and returns the poster the movie referenced before
+// and the new version. It returns ErrMovieNotFound when the movie does not exist or is deleted.
+func (r *MoviesRepository) Patch(c context.Context, id int, patch models.MoviePatch) (string, int, error) {
+	var previousPoster *string
+	var version int
+
+	_, err := audited(c, r.db, movieAudit, auditActionUpdate, id, func(tx pgx.Tx) (int, error) {
+		err := tx.QueryRow(c, "select poster_url, version from movies where id = $1 and deleted_at is null for update", id).Scan(&previousPoster, &version)
+		if err != nil {
+			return 0, notFound(err, ErrMovieNotFound)
+		}
+
+		err = checkVersion(patch.Version, version)
 		if err != nil {
 			return 0, err
 		}
-	}
 
-	err = tx.Commit(c)
+		params := pgx.NamedArgs{
+			"id": id,
+		}
+		// Genre changes count as a change of the movie, so the version is bumped even without column changes.
+		assignments := []string{"version = version + 1", "updated_at = now()"}
+
+		columns := []struct {
+			name  string
+			value any
+			set   bool
+		}{
+			{"title", patch.Title, patch.Title != nil},
+			{"description", patch.Description, patch.Description != nil},
+			{"release_year", patch.ReleaseYear, patch.ReleaseYear != nil},
+			{"director", patch.Director, patch.Director != nil},
+			{"trailer_url", patch.TrailerUrl, patch.TrailerUrl != nil},
+			{"poster_url", patch.PosterUrl, patch.PosterUrl != nil},
+		}
+		for _, column := range columns {
+			if column.set {
+				assignments = append(assignments, fmt.Sprintf("%s = @%s", column.name, column.name))
+				params[column.name] = column.value
+			}
+		}
+
+		err = tx.QueryRow(c, fmt.Sprintf("update movies set %s where id = @id returning version", strings.Join(assignments, ", ")), params).Scan(&version)
+		if err != nil {
+			return 0, err
+		}
+
+		if patch.Genres != nil {
+			_, err = tx.Exec(c, "delete from movies_genres where movie_id = $1", id)
+			if err != nil {
+				return 0, err
+			}
+
+			for _, genre := range *patch.Genres {
+				_, err := tx.Exec(c, "insert into movies_genres(movie_id, genre_id) values ($1, $2)", id, genre.Id)
+				if err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		return id, nil
+	})
 	if err != nil {
-		return 0, err
+		return "", 0, err
 	}
 
-	return id, nil
+	if previousPoster == nil {
+		return "", version, nil
+	}
+
+	return *previousPoster, version, nil
 }
 
-func (r *MoviesRepository) Update(c context.Context, id int, updatedMovie models.Movie) error {
-	tx, err := r.db.Begin(c)
+// Delete marks the movie as deleted unless its version differs from the expected one. Its genres, ratings,
+// watch list entries and poster are kept until Purge, so that Restore brings it back as it was.
+func (r *MoviesRepository) Delete(c context.Context, id int, version int) error {
+	_, err := audited(c, r.db, movieAudit, auditActionDelete, id, func(tx pgx.Tx) (int, error) {
+		return id, softDelete(c, tx, "movies", id, version)
+	})
+
+	return err
+}
+
+// Restore brings back a deleted movie and returns its version.
+func (r *MoviesRepository) Restore(c context.Context, id int) (int, error) {
+	var version int
+	_, err := audited(c, r.db, movieAudit, auditActionRestore, id, func(tx pgx.Tx) (int, error) {
+		var err error
+		version, err = restore(c, tx, "movies", id)
+		return id, err
+	})
 	if err != nil {
-		return err
+		return 0, notFound(err, ErrMovieNotFound)
 	}
-	defer tx.Rollback(c)
 
-	_, err = tx.Exec(c, "update movies set title = $1, description = $2, release_year =$3, director = $4, trailer_url = $5, poster_url = $6  where id = $7", updatedMovie.Title, updatedMovie.Description, updatedMovie.ReleaseYear, updatedMovie.Director, updatedMovie.TrailerUrl, updatedMovie.PosterUrl, id)
+	return version, nil
+}
+
+// Purge removes the movies deleted before the cutoff together with everything referencing them,
+// and returns the posters they referenced.
+func (r *MoviesRepository) Purge(c context.Context, cutoff time.Time) ([]string, error) {
+	tx, err := r.db.Begin(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback(c)
 
-	_, err = tx.Exec(c, "delete from movies_genres where movie_id = $1", id)
-	if err != nil {
-		return err
+	ids, err := lockDeletedBefore(c, tx, "movies", cutoff)
+	if err != nil || len(ids) == 0 {
+		return nil, err
 	}
 
-	for _, genre := range updatedMovie.Genres {
-		_, err := tx.Exec(c, "insert into movies_genres(movie_id, genre_id) values ($1, $2)", id, genre.Id)
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		_, err = auditedTx(c, tx, movieAudit, auditActionPurge, id, func(tx pgx.Tx) (int, error) {
+			for _, table := range []string{"watch_list", "user_movie_state", "movies_genres"} {
+				_, err := tx.Exec(c, fmt.Sprintf("delete from %s where movie_id = $1", table), id)
+				if err != nil {
+					return 0, err
+				}
+			}
+
+			var key string
+			err := tx.QueryRow(c, "delete from movies where id = $1 returning coalesce(poster_url, '')", id).Scan(&key)
+			keys = append(keys, key)
+
+			return id, err
+		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	err = tx.Commit(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return keys, nil
 }
 
-func (r *MoviesRepository) Delete(c context.Context, id int) error {
-	tx, err := r.db.Begin(c)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(c)
+func (r *MoviesRepository) SetRating(c context.Context, id int, userId int, rating int) error {
+	tag, err := r.db.Exec(c, `insert into user_movie_state (user_id, movie_id, rating)
+select $1::int4, m.id, $3::int4 from movies m where m.id = $2 and m.deleted_at is null
+on conflict (user_id, movie_id) do update set rating = excluded.rating, updated_at = now()`, userId, id, rating)
 
-	_, err = tx.Exec(c, "delete from watch_list where movie_id = $1", id)
-	if err != nil {
-		return err
-	}
+	return movieStateWritten(tag.RowsAffected(), err)
+}
+
+func (r *MoviesRepository) SetWatched(c context.Context, id int, userId int, isWatched bool) error {
+	tag, err := r.db.Exec(c, `insert into user_movie_state (user_id, movie_id, is_watched)
+select $1::int4, m.id, $3::bool from movies m where m.id = $2 and m.deleted_at is null
+on conflict (user_id, movie_id) do update set is_watched = excluded.is_watched, updated_at = now()`, userId, id, isWatched)
 
-	_, err = tx.Exec(c, "delete from movies_genres where movie_id = $1", id)
+	return movieStateWritten(tag.RowsAffected(), err)
+}
+
+// movieStateWritten reports ErrMovieNotFound when an upsert of a user's movie state wrote nothing,
+// because the movie does not exist or is deleted.
+func movieStateWritten(rowsAffected int64, err error) error {
 	if err != nil {
-		return err
+		return referencedNotFound(err, ErrMovieNotFound)
+	}
+	if rowsAffected == 0 {
+		return ErrMovieNotFound
 	}
 
-	_, err = tx.Exec(c, "delete from movies where id = $1", id)
+	return nil
+}
+
+// PosterKeys returns the storage keys of every poster referenced by a movie, deleted ones included
+// until they are purged.
+func (r *MoviesRepository) PosterKeys(c context.Context) (map[string]bool, error) {
+	rows, err := r.db.Query(c, "select distinct poster_url from movies where coalesce(poster_url, '') <> ''")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = tx.Commit(c)
+	keys := make(map[string]bool)
+	var key string
+	_, err = pgx.ForEachRow(rows, []any{&key}, func() error {
+		keys[key] = true
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return keys, nil
 }
 
-func (r *MoviesRepository) SetRating(c context.Context, id int, rating int) error {
-	_, err := r.db.Exec(c, "update movies set rating = $1 where id = $2", rating, id)
-
-	return err
+// escapeHtml returns an expression escaping the text column for use in HTML; & goes first,
+// so that the entities of the other replacements are not escaped again.
+func escapeHtml(column string) string {
+	return fmt.Sprintf("replace(replace(replace(coalesce(%s, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')", column)
 }
 
-func (r *MoviesRepository) SetWatched(c context.Context, id int, isWatched bool) error {
-	_, err := r.db.Exec(c, "update movies set is_watched = $1 where id = $2", isWatched, id)
-
-	return err
+// escapeLike escapes the wildcards of a user supplied ilike pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvalidCursor = apperrors.Validation("Invalid cursor", apperrors.Field("cursor", "cursor is malformed or belongs to a different ordering"))
+
+// sortKey is one column of a keyset ordering. expr must never be null, otherwise rows
+// around the null would be skipped when paging.
+type sortKey struct {
+	expr    string
+	sqlType string
+	desc    bool
+}
+
+type cursor struct {
+	Order  string   `json:"o"`
+	Values []string `json:"v"`
+}
+
+func orderByClause(keys []sortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		direction := "asc"
+		if key.desc {
+			direction = "desc"
+		}
+
+		parts = append(parts, fmt.Sprintf("%s %s", key.expr, direction))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// sortValuesSelect selects the sort key values as text columns named sort_key_<n>,
+// so the last row of a page can be turned into the next cursor.
+func sortValuesSelect(keys []sortKey) string {
+	parts := make([]string, 0, len(keys))
+	for i, key := range keys {
+		parts = append(parts, fmt.Sprintf("(%s)::text as sort_key_%d", key.expr, i))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// sortValuesColumns lists the sort_key_<n> columns selected by sortValuesSelect from the given relation.
+func sortValuesColumns(relation string, count int) string {
+	parts := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		parts = append(parts, fmt.Sprintf("%s.sort_key_%d", relation, i))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition returns a condition matching the rows that come after the cursor position
+// in the given ordering and binds the cursor values to params.
+func keysetCondition(keys []sortKey, values []string, params pgx.NamedArgs) string {
+	disjuncts := make([]string, 0, len(keys))
+
+	for i, key := range keys {
+		conjuncts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, fmt.Sprintf("%s = @cursor%d::text::%s", keys[j].expr, j, keys[j].sqlType))
+		}
+
+		operator := ">"
+		if key.desc {
+			operator = "<"
+		}
+		conjuncts = append(conjuncts, fmt.Sprintf("%s %s @cursor%d::text::%s", key.expr, operator, i, key.sqlType))
+
+		disjuncts = append(disjuncts, fmt.Sprintf("(%s)", strings.Join(conjuncts, " and ")))
+	}
+
+	for i, value := range values {
+		params[fmt.Sprintf("cursor%d", i)] = value
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(disjuncts, " or "))
+}
+
+func encodeCursor(keys []sortKey, values []string) string {
+	b, _ := json.Marshal(cursor{
+		Order:  orderSignature(keys),
+		Values: values,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor rejects cursors that were issued for a different ordering.
+func decodeCursor(keys []sortKey, encoded string) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var decoded cursor
+	err = json.Unmarshal(b, &decoded)
+	if err != nil || decoded.Order != orderSignature(keys) || len(decoded.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	return decoded.Values, nil
+}
+
+func orderSignature(keys []sortKey) string {
+	h := fnv.New32a()
+	h.Write([]byte(orderByClause(keys)))
+
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// pageClause binds either the keyset condition for the cursor or the offset to params and returns
+// the where-condition and the limit clause for one page plus a lookahead row.
+func pageClause(keys []sortKey, page models.PageRequest, params pgx.NamedArgs) (string, string, error) {
+	condition := "true"
+	offset := page.Offset
+
+	if page.Cursor != "" {
+		values, err := decodeCursor(keys, page.Cursor)
+		if err != nil {
+			return "", "", err
+		}
+
+		condition = keysetCondition(keys, values, params)
+		offset = 0
+	}
+
+	params["pageLimit"] = page.Limit + 1
+	params["pageOffset"] = offset
+
+	return condition, "limit @pageLimit offset @pageOffset", nil
+}
+
+// buildPage drops the lookahead row; when it was there, the last kept row becomes the next cursor.
+func buildPage[T any](keys []sortKey, page models.PageRequest, items []T, sortValues [][]string, total int) models.Page[T] {
+	result := models.Page[T]{
+		Items: items,
+		Total: total,
+	}
+
+	if len(items) > page.Limit {
+		result.Items = items[:page.Limit]
+		result.NextCursor = encodeCursor(keys, sortValues[page.Limit-1])
+	}
+
+	return result
+}
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"filmservice/models"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var testKeys = []sortKey{
+	{expr: "coalesce(m.title, '')", sqlType: "text"},
+	{expr: "m.id", sqlType: "int4", desc: true},
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	values := []string{"Alien", "42"}
+
+	decoded, err := decodeCursor(testKeys, encodeCursor(testKeys, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(decoded, values) {
+		t.Errorf("decoded %q, want %q", decoded, values)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	otherOrder := []sortKey{
+		{expr: "coalesce(m.title, '')", sqlType: "text", desc: true},
+		{expr: "m.id", sqlType: "int4", desc: true},
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "not base64", encoded: "!!!"},
+		{name: "not json", encoded: base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{name: "other ordering", encoded: encodeCursor(otherOrder, []string{"Alien", "42"})},
+		{name: "too few values", encoded: encodeCursor(testKeys, []string{"Alien"})},
+		{name: "too many values", encoded: encodeCursor(testKeys, []string{"Alien", "42", "x"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(testKeys, tt.encoded)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestOrderSignatureDependsOnDirection(t *testing.T) {
+	asc := []sortKey{{expr: "m.id", sqlType: "int4"}}
+	desc := []sortKey{{expr: "m.id", sqlType: "int4", desc: true}}
+
+	if orderSignature(asc) == orderSignature(desc) {
+		t.Error("ascending and descending orderings share a signature")
+	}
+	if orderSignature(asc) != orderSignature([]sortKey{{expr: "m.id", sqlType: "int4"}}) {
+		t.Error("equal orderings have different signatures")
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	params := pgx.NamedArgs{}
+
+	condition := keysetCondition(testKeys, []string{"Alien", "42"}, params)
+
+	want := "((coalesce(m.title, '') > @cursor0::text::text) or " +
+		"(coalesce(m.title, '') = @cursor0::text::text and m.id < @cursor1::text::int4))"
+	if condition != want {
+		t.Errorf("condition\n%s\nwant\n%s", condition, want)
+	}
+	if params["cursor0"] != "Alien" || params["cursor1"] != "42" {
+		t.Errorf("params %v do not bind the cursor values", params)
+	}
+}
+
+func TestPageClause(t *testing.T) {
+	t.Run("offset", func(t *testing.T) {
+		params := pgx.NamedArgs{}
+
+		condition, limit, err := pageClause(testKeys, models.PageRequest{Limit: 20, Offset: 40}, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if condition != "true" || limit != "limit @pageLimit offset @pageOffset" {
+			t.Errorf("got %q and %q", condition, limit)
+		}
+		if params["pageLimit"] != 21 || params["pageOffset"] != 40 {
+			t.Errorf("params %v, want a lookahead row and the offset", params)
+		}
+	})
+
+	t.Run("cursor ignores offset", func(t *testing.T) {
+		params := pgx.NamedArgs{}
+		page := models.PageRequest{Limit: 20, Offset: 40, Cursor: encodeCursor(testKeys, []string{"Alien", "42"})}
+
+		condition, _, err := pageClause(testKeys, page, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(condition, "@cursor1") {
+			t.Errorf("condition %q is not a keyset condition", condition)
+		}
+		if params["pageOffset"] != 0 {
+			t.Errorf("offset %v, want 0", params["pageOffset"])
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, _, err := pageClause(testKeys, models.PageRequest{Limit: 20, Cursor: "garbage"}, pgx.NamedArgs{})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("got %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestBuildPage(t *testing.T) {
+	genres := func(ids ...int) []models.Genre {
+		result := make([]models.Genre, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, models.Genre{Id: id})
+		}
+		return result
+	}
+
+	// Rows are movies with their genres already aggregated, one row per movie.
+	movies := []models.Movie{
+		{Id: 1, Genres: genres(1, 2, 3)},
+		{Id: 2, Genres: genres(2)},
+		{Id: 3, Genres: genres(1, 3)},
+	}
+	sortValues := [][]string{{"A", "1"}, {"B", "2"}, {"C", "3"}}
+
+	t.Run("lookahead row is dropped", func(t *testing.T) {
+		page := buildPage(testKeys, models.PageRequest{Limit: 2}, movies, sortValues, 10)
+
+		if len(page.Items) != 2 || page.Total != 10 {
+			t.Fatalf("got %d items of %d, want 2 of 10", len(page.Items), page.Total)
+		}
+		for i, movie := range page.Items {
+			if len(movie.Genres) != len(movies[i].Genres) {
+				t.Errorf("movie %d has %d genres, want all %d", movie.Id, len(movie.Genres), len(movies[i].Genres))
+			}
+		}
+
+		next, err := decodeCursor(testKeys, page.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(next, sortValues[1]) {
+			t.Errorf("next cursor points at %q, want the last kept row %q", next, sortValues[1])
+		}
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		page := buildPage(testKeys, models.PageRequest{Limit: 3}, movies, sortValues, 3)
+
+		if len(page.Items) != 3 || page.NextCursor != "" {
+			t.Errorf("got %d items and cursor %q, want 3 and none", len(page.Items), page.NextCursor)
+		}
+	})
+}
+
+func TestMovieGenresSelectIsPerMovie(t *testing.T) {
+	// Genres are aggregated by a subquery correlated with the paged row rather than joined,
+	// so the limit counts movies and a page can never cut a movie's genres in two.
+	sql := movieGenresSelect("p")
+
+	if !strings.Contains(sql, "where mg.movie_id = p.id") || !strings.Contains(sql, "json_agg(") {
+		t.Errorf("genres are not aggregated per row of p:\n%s", sql)
+	}
+	if !strings.Contains(sql, "g.deleted_at is null") {
+		t.Errorf("deleted genres are not left out:\n%s", sql)
+	}
+}
+
+func TestMovieSort(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []models.SortField
+		searching bool
+		want      string
+		invalid   bool
+	}{
+		{
+			name: "default is by id",
+			want: "m.id asc",
+		},
+		{
+			name:   "id breaks ties",
+			fields: []models.SortField{{Field: "release_year", Desc: true}},
+			want:   "coalesce(m.release_year, 0) desc, m.id asc",
+		},
+		{
+			name:   "explicit id is not repeated",
+			fields: []models.SortField{{Field: "id", Desc: true}, {Field: "title"}},
+			want:   "m.id desc, coalesce(m.title, '') asc",
+		},
+		{
+			name:      "searches default to relevance",
+			searching: true,
+			want:      movieRelevanceKey.expr + " desc, m.id asc",
+		},
+		{
+			name:    "relevance needs a search",
+			fields:  []models.SortField{{Field: "relevance"}},
+			invalid: true,
+		},
+		{
+			name:    "unknown field",
+			fields:  []models.SortField{{Field: "password_hash"}},
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := movieSort(tt.fields, tt.searching)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Errorf("got %v, want ErrInvalidSort", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := orderByClause(keys); got != tt.want {
+				t.Errorf("order by %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
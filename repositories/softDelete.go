@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// softDelete marks the row with the given id as deleted unless its version differs from the expected one.
+// Deleting a missing or already deleted row is not an error.
+func softDelete(c context.Context, tx pgx.Tx, table string, id int, version int) error {
+	tag, err := tx.Exec(c, fmt.Sprintf(`update %s set deleted_at = now(), version = version + 1, updated_at = now()
+where id = $1 and deleted_at is null and ($2 = 0 or version = $2)`, table), id, version)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		err = missingOrModified(c, tx, table, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// restore clears the deletion mark of the row with the given id and returns its version.
+// Restoring a row that is not deleted leaves it untouched; pgx.ErrNoRows means it does not exist, e.g. was purged.
+func restore(c context.Context, tx pgx.Tx, table string, id int) (int, error) {
+	var version int
+	err := tx.QueryRow(c, fmt.Sprintf(`update %s set deleted_at = null, version = version + 1, updated_at = now()
+where id = $1 and deleted_at is not null returning version`, table), id).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(c, fmt.Sprintf("select version from %s where id = $1", table), id).Scan(&version)
+	}
+
+	return version, err
+}
+
+// lockDeletedBefore locks the rows deleted before the cutoff for a purge and returns their ids,
+// so that a concurrent restore either happens first or finds the row gone.
+func lockDeletedBefore(c context.Context, tx pgx.Tx, table string, cutoff time.Time) ([]int, error) {
+	rows, err := tx.Query(c, fmt.Sprintf("select id from %s where deleted_at < $1 for update", table), cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// deletedFilter is the condition that hides soft deleted rows of the given alias unless they are included.
+func deletedFilter(alias string, includeDeleted bool) string {
+	if includeDeleted {
+		return "true"
+	}
+
+	return alias + ".deleted_at is null"
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"filmservice/apperrors"
+	"filmservice/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrSessionRevoked = apperrors.Unauthorized("Invalid refresh token")
+
+type TokensRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokensRepository(conn *pgxpool.Pool) *TokensRepository {
+	return &TokensRepository{db: conn}
+}
+
+func (r *TokensRepository) CreateSession(c context.Context, session models.Session) error {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	_, err = tx.Exec(c, "delete from refresh_tokens where user_id = $1 and expires_at < now()", session.UserId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, `insert into refresh_tokens (user_id, token_hash, access_token_id, access_expires_at, expires_at)
+values ($1, $2, $3, $4, $5)`, session.UserId, session.TokenHash, session.AccessTokenId, session.AccessExpiresAt, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+func (r *TokensRepository) FindSessionByHash(c context.Context, tokenHash string) (models.Session, error) {
+	var session models.Session
+	row := r.db.QueryRow(c, `select id, user_id, token_hash, access_token_id, access_expires_at, expires_at, revoked_at
+from refresh_tokens where token_hash = $1`, tokenHash)
+
+	err := row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.TokenHash,
+		&session.AccessTokenId,
+		&session.AccessExpiresAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	return session, nil
+}
+
+// Rotate revokes the session with the given id and stores its replacement in one transaction.
+// It returns ErrSessionRevoked when the session was revoked concurrently.
+func (r *TokensRepository) Rotate(c context.Context, id int, next models.Session) error {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	tag, err := tx.Exec(c, "update refresh_tokens set revoked_at = now() where id = $1 and revoked_at is null", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionRevoked
+	}
+
+	_, err = tx.Exec(c, `insert into refresh_tokens (user_id, token_hash, access_token_id, access_expires_at, expires_at)
+values ($1, $2, $3, $4, $5)`, next.UserId, next.TokenHash, next.AccessTokenId, next.AccessExpiresAt, next.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+// RevokeAccessToken revokes the access token and the session it was issued with.
+func (r *TokensRepository) RevokeAccessToken(c context.Context, jti string, expiresAt time.Time) error {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	_, err = tx.Exec(c, "delete from revoked_tokens where expires_at < now()")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, "insert into revoked_tokens (jti, expires_at) values ($1, $2) on conflict (jti) do nothing", jti, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, "update refresh_tokens set revoked_at = now() where access_token_id = $1 and revoked_at is null", jti)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+// RevokeAllForUser ends every session of the user, including access tokens that have not expired yet.
+func (r *TokensRepository) RevokeAllForUser(c context.Context, userId int) error {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	err = revokeAllForUser(c, tx, userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+// revokeAllForUser is RevokeAllForUser within a transaction of the caller.
+func revokeAllForUser(c context.Context, tx pgx.Tx, userId int) error {
+	_, err := tx.Exec(c, "delete from revoked_tokens where expires_at < now()")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, `insert into revoked_tokens (jti, expires_at)
+select access_token_id, access_expires_at from refresh_tokens where user_id = $1 and access_expires_at > now()
+on conflict (jti) do nothing`, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, "update refresh_tokens set revoked_at = now() where user_id = $1 and revoked_at is null", userId)
+
+	return err
+}
+
+func (r *TokensRepository) IsRevoked(c context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow(c, "select exists (select 1 from revoked_tokens where jti = $1)", jti).Scan(&revoked)
+
+	return revoked, err
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"filmservice/apperrors"
+	"filmservice/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInvalidUserToken = apperrors.Validation("Token is invalid or has expired", apperrors.Field("token", "token is invalid or has expired"))
+
+type UserTokensRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserTokensRepository(conn *pgxpool.Pool) *UserTokensRepository {
+	return &UserTokensRepository{db: conn}
+}
+
+// Create stores a new token and invalidates the user's unused tokens issued for the same purpose.
+func (r *UserTokensRepository) Create(c context.Context, token models.UserToken) error {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	_, err = tx.Exec(c, "delete from user_tokens where user_id = $1 and purpose = $2 and used_at is null",
+		token.UserId, token.Purpose)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(c, "insert into user_tokens (user_id, purpose, token_hash, expires_at) values ($1, $2, $3, $4)",
+		token.UserId, token.Purpose, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+// VerifyEmail consumes an email verification token and marks its user's email as verified.
+func (r *UserTokensRepository) VerifyEmail(c context.Context, tokenHash string) (int, error) {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(c)
+
+	userId, err := consumeUserToken(c, tx, tokenHash, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = auditedTx(c, tx, userAudit, auditActionVerifyEmail, userId, func(tx pgx.Tx) (int, error) {
+		_, err := tx.Exec(c, "update users set email_verified_at = now(), version = version + 1, updated_at = now() where id = $1 and email_verified_at is null", userId)
+		return userId, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, tx.Commit(c)
+}
+
+// ResetPassword consumes a password reset token and replaces its user's password hash.
+func (r *UserTokensRepository) ResetPassword(c context.Context, tokenHash string, passwordHash string) (int, error) {
+	tx, err := r.db.Begin(c)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(c)
+
+	userId, err := consumeUserToken(c, tx, tokenHash, models.TokenPurposePasswordReset)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = auditedTx(c, tx, userAudit, auditActionResetPassword, userId, func(tx pgx.Tx) (int, error) {
+		_, err := tx.Exec(c, "update users set password_hash = $1, version = version + 1, updated_at = now() where id = $2", passwordHash, userId)
+		return userId, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, tx.Commit(c)
+}
+
+// consumeUserToken marks an unused, unexpired token of an active user as used and returns the id of its user.
+func consumeUserToken(c context.Context, tx pgx.Tx, tokenHash string, purpose string) (int, error) {
+	var userId int
+	err := tx.QueryRow(c, `update user_tokens set used_at = now()
+where token_hash = $1 and purpose = $2 and used_at is null and expires_at > now()
+	and user_id in (select id from users where deleted_at is null)
+returning user_id`, tokenHash, purpose).Scan(&userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrInvalidUserToken
+	}
+
+	return userId, err
+}
@@ -2,12 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"filmservice/apperrors"
 	"filmservice/models"
+	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrEmailTaken = apperrors.Conflict("Email is already taken")
+
 type UsersRepository struct {
 	db *pgxpool.Pool
 }
@@ -18,78 +25,209 @@ func NewUsersRepository(conn *pgxpool.Pool) *UsersRepository {
 
 func (r *UsersRepository) FindByEmail(c *gin.Context, email string) (models.User, error) {
 	var user models.User
-	row := r.db.QueryRow(c, "select id, name, email, password_hash from users where email = $1", email)
+	row := r.db.QueryRow(c, "select id, name, email, password_hash, role, email_verified_at, version, updated_at from users where email = $1 and deleted_at is null", email)
 
-	err := row.Scan(&user.Id, &user.Name, &user.Email, &user.PasswordHash)
+	err := row.Scan(&user.Id, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.EmailVerifiedAt, &user.Version, &user.UpdatedAt)
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, notFound(err, ErrUserNotFound)
 	}
 
 	return user, nil
 }
 
-func (r *UsersRepository) FindAll(c *gin.Context) ([]models.User, error) {
-	rows, err := r.db.Query(c, "select id, name, email, password_hash from users order by id")
+var userSortKeys = []sortKey{
+	{expr: "id", sqlType: "int4"},
+}
+
+// FindAll lists the users, soft deleted ones only when includeDeleted is set.
+func (r *UsersRepository) FindAll(c *gin.Context, page models.PageRequest, includeDeleted bool) (models.Page[models.User], error) {
+	filter := deletedFilter("users", includeDeleted)
+
+	var total int
+	err := r.db.QueryRow(c, fmt.Sprintf("select count(*) from users where %s", filter)).Scan(&total)
+	if err != nil {
+		return models.Page[models.User]{}, err
+	}
+
+	params := pgx.NamedArgs{}
+	condition, limit, err := pageClause(userSortKeys, page, params)
 	if err != nil {
-		return nil, err
+		return models.Page[models.User]{}, err
 	}
 
+	sql := fmt.Sprintf("select id, name, email, password_hash, role, version, updated_at, deleted_at, %s from users where %s and %s order by %s %s",
+		sortValuesSelect(userSortKeys), filter, condition, orderByClause(userSortKeys), limit)
+
+	rows, err := r.db.Query(c, sql, params)
+	if err != nil {
+		return models.Page[models.User]{}, err
+	}
+	defer rows.Close()
+
 	users := make([]models.User, 0)
+	sortValues := make([][]string, 0)
+
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.PasswordHash)
+		var id string
+		err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.Version, &user.UpdatedAt, &user.DeletedAt, &id)
 		if err != nil {
-			return nil, err
+			return models.Page[models.User]{}, err
 		}
 
 		users = append(users, user)
+		sortValues = append(sortValues, []string{id})
 	}
-	if rows.Err() != nil {
-		return nil, err
+
+	err = rows.Err()
+	if err != nil {
+		return models.Page[models.User]{}, err
 	}
 
-	return users, nil
+	return buildPage(userSortKeys, page, users, sortValues, total), nil
 }
 
 func (r *UsersRepository) FindById(c context.Context, id int) (models.User, error) {
 	var user models.User
-	row := r.db.QueryRow(c, "select id, name, email from users where id = $1", id)
+	row := r.db.QueryRow(c, "select id, name, email, password_hash, role, email_verified_at, version, updated_at from users where id = $1 and deleted_at is null", id)
 
-	err := row.Scan(&user.Id, &user.Name, &user.Email)
+	err := row.Scan(&user.Id, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.EmailVerifiedAt, &user.Version, &user.UpdatedAt)
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, notFound(err, ErrUserNotFound)
 	}
 
 	return user, nil
 }
 
 func (r *UsersRepository) Create(c *gin.Context, user models.User) (int, error) {
-	var id int
-
-	err := r.db.QueryRow(c, "insert into users (name, email, password_hash) values ($1, $2, $3) returning id",
-		user.Name, user.Email, user.PasswordHash).Scan(&id)
+	id, err := audited(c, r.db, userAudit, auditActionCreate, 0, func(tx pgx.Tx) (int, error) {
+		var id int
+		err := tx.QueryRow(c, "insert into users (name, email, password_hash, role, email_verified_at) values ($1, $2, $3, $4, $5) returning id",
+			user.Name, user.Email, user.PasswordHash, user.Role, user.EmailVerifiedAt).Scan(&id)
+
+		return id, err
+	})
+	if isUniqueViolation(err) {
+		return 0, ErrEmailTaken
+	}
 
 	return id, err
 }
 
-func (r *UsersRepository) Update(c *gin.Context, updatedUser models.User) error {
-	_, err := r.db.Exec(c, "update users set name = $1, email = $2 where id = $3", updatedUser.Name,
-		updatedUser.Email, updatedUser.Id)
+// Update changes the user unless its version differs from updatedUser.Version, and returns the new version.
+// A role change ends the user's sessions, since access tokens carry the role they were issued with.
+func (r *UsersRepository) Update(c *gin.Context, updatedUser models.User) (int, error) {
+	var version int
+
+	_, err := audited(c, r.db, userAudit, auditActionUpdate, updatedUser.Id, func(tx pgx.Tx) (int, error) {
+		// An empty role leaves the current one untouched. The row is locked, so old still holds the role before the update.
+		var roleChanged bool
+		err := tx.QueryRow(c, `update users u set name = $1, email = $2, role = coalesce(nullif($3, ''), u.role), version = u.version + 1, updated_at = now()
+from (select role from users where id = $4) old
+where u.id = $4 and u.deleted_at is null and ($5 = 0 or u.version = $5)
+returning u.version, u.role <> old.role`,
+			updatedUser.Name, updatedUser.Email, updatedUser.Role, updatedUser.Id, updatedUser.Version).Scan(&version, &roleChanged)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, notFound(missingOrModified(c, tx, "users", updatedUser.Id), ErrUserNotFound)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if roleChanged {
+			err = revokeAllForUser(c, tx, updatedUser.Id)
+		}
 
-	return err
+		return updatedUser.Id, err
+	})
+	if isUniqueViolation(err) {
+		return 0, ErrEmailTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// ChangePassword sets the password hash unless the user's version differs from user.Version, and returns the new version.
+func (r *UsersRepository) ChangePassword(c *gin.Context, user models.User) (int, error) {
+	var version int
+
+	_, err := audited(c, r.db, userAudit, auditActionChangePassword, user.Id, func(tx pgx.Tx) (int, error) {
+		err := tx.QueryRow(c, "update users set password_hash = $1, version = version + 1, updated_at = now() where id = $2 and deleted_at is null and ($3 = 0 or version = $3) returning version",
+			user.PasswordHash, user.Id, user.Version).Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, notFound(missingOrModified(c, tx, "users", user.Id), ErrUserNotFound)
+		}
+
+		return user.Id, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
 }
 
-func (r *UsersRepository) ChangePassword(c *gin.Context, user models.User) error {
-	_, err := r.db.Exec(c, "update users set password_hash = $1 where id = $2", user.PasswordHash, user.Id)
+// Delete marks the user as deleted unless its version differs from the expected one. The account can
+// no longer sign in, and its email can be registered again. Its sessions end in the same transaction,
+// so they stay valid when the deletion fails.
+func (r *UsersRepository) Delete(c context.Context, id int, version int) error {
+	_, err := audited(c, r.db, userAudit, auditActionDelete, id, func(tx pgx.Tx) (int, error) {
+		err := softDelete(c, tx, "users", id, version)
+		if err != nil {
+			return 0, err
+		}
+
+		return id, revokeAllForUser(c, tx, id)
+	})
 
 	return err
 }
 
-func (r *UsersRepository) Delete(c context.Context, id int) error {
-	_, err := r.db.Exec(c, "delete from users where id = $1", id)
+// Restore brings back a deleted user and returns its version. It fails with ErrEmailTaken when
+// another account registered the email in the meantime.
+func (r *UsersRepository) Restore(c context.Context, id int) (int, error) {
+	var version int
+	_, err := audited(c, r.db, userAudit, auditActionRestore, id, func(tx pgx.Tx) (int, error) {
+		var err error
+		version, err = restore(c, tx, "users", id)
+		return id, err
+	})
+	if isUniqueViolation(err) {
+		return 0, ErrEmailTaken
+	}
+	if err != nil {
+		return 0, notFound(err, ErrUserNotFound)
+	}
+
+	return version, nil
+}
+
+// Purge removes the users deleted before the cutoff for good, together with their sessions,
+// ratings and watch lists, and returns how many were removed.
+func (r *UsersRepository) Purge(c context.Context, cutoff time.Time) (int, error) {
+	tx, err := r.db.Begin(c)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback(c)
+
+	ids, err := lockDeletedBefore(c, tx, "users", cutoff)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		_, err = auditedTx(c, tx, userAudit, auditActionPurge, id, func(tx pgx.Tx) (int, error) {
+			_, err := tx.Exec(c, "delete from users where id = $1", id)
+			return id, err
+		})
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return len(ids), tx.Commit(c)
 }
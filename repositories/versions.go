@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"filmservice/apperrors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryRower is implemented by both the pool and transactions.
+type queryRower interface {
+	QueryRow(c context.Context, sql string, args ...any) pgx.Row
+}
+
+// AnyVersion skips the optimistic concurrency check of an update or delete.
+const AnyVersion = 0
+
+// ErrVersionMismatch is returned when a row was changed since the client read the version it sent.
+var ErrVersionMismatch = apperrors.PreconditionFailed("The resource has been modified since it was read, reload it and try again")
+
+// checkVersion compares the version a client expects with the current one of a row locked by the caller.
+func checkVersion(expected int, current int) error {
+	if expected != AnyVersion && expected != current {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// missingOrModified explains why a conditional write to the row with the given id matched nothing:
+// pgx.ErrNoRows when the row does not exist or is soft deleted, ErrVersionMismatch otherwise.
+func missingOrModified(c context.Context, db queryRower, table string, id int) error {
+	var exists bool
+	err := db.QueryRow(c, fmt.Sprintf("select exists (select 1 from %s where id = $1 and deleted_at is null)", table), id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return pgx.ErrNoRows
+	}
+
+	return ErrVersionMismatch
+}
@@ -3,7 +3,9 @@ package repositories
 import (
 	"context"
 	"filmservice/models"
+	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,49 +17,136 @@ func NewWatchListRepository(conn *pgxpool.Pool) *WatchListRepository {
 	return &WatchListRepository{db: conn}
 }
 
-func (r *WatchListRepository) GetAll(c context.Context) (
-	[]models.Movie,
+var watchListSortKeys = []sortKey{
+	{expr: "COALESCE(wl.added_at, 'epoch'::TIMESTAMP)", sqlType: "TIMESTAMP"},
+	{expr: "wl.id", sqlType: "INT4"},
+}
+
+func (r *WatchListRepository) GetAll(
+	c context.Context,
+	userId int,
+	page models.PageRequest,
+) (
+	models.Page[models.Movie],
 	error,
 ) {
-	sql := `SELECT
-    m.id,
-    m.title,
-    m.description,
-    m.release_year,
-    m.director,
-    m.rating,
-    m.is_watched,
-    m.trailer_url,
-    m.poster_url,
-    g.id,
-    g.title
-FROM watch_list wl
-JOIN movies m ON m.id = wl.movie_id
-JOIN movies_genres mg ON mg.movie_id = m.id
-JOIN genres g ON g.id = mg.genre_id
-ORDER BY wl.added_at ASC;
-	`
+	where := `WHERE wl.user_id = @userId AND m.deleted_at IS NULL`
+
+	params := pgx.NamedArgs{
+		"userId": userId,
+	}
+
+	var total int
+	err := r.db.QueryRow(
+		c,
+		fmt.Sprintf(
+			"SELECT COUNT(*) FROM watch_list wl JOIN movies m ON m.id = wl.movie_id %s",
+			where,
+		),
+		params,
+	).Scan(&total)
+	if err != nil {
+		return models.Page[models.Movie]{}, err
+	}
+
+	condition, limit, err := pageClause(
+		watchListSortKeys,
+		page,
+		params,
+	)
+	if err != nil {
+		return models.Page[models.Movie]{}, err
+	}
+
+	// The page is cut from the list first, so that genres are only aggregated for its rows.
+	sql := fmt.Sprintf(`WITH page AS (
+    SELECT
+        m.id,
+        m.title,
+        m.description,
+        m.release_year,
+        m.director,
+        COALESCE(ums.rating, 0) AS rating,
+        COALESCE(ums.is_watched, FALSE) AS is_watched,
+        COALESCE(rs.average_rating, 0) AS average_rating,
+        rs.ratings_count,
+        m.trailer_url,
+        m.poster_url,
+        m.version,
+        m.updated_at,
+        %s,
+        ROW_NUMBER() OVER (ORDER BY %s) AS position
+    FROM watch_list wl
+    JOIN movies m ON m.id = wl.movie_id
+    LEFT JOIN user_movie_state ums ON ums.movie_id = m.id AND ums.user_id = wl.user_id
+    LEFT JOIN LATERAL (
+        SELECT AVG(s.rating)::FLOAT8 AS average_rating, COUNT(s.rating) AS ratings_count
+        FROM user_movie_state s
+        WHERE s.movie_id = m.id
+    ) rs ON TRUE
+    %s
+      AND %s
+    ORDER BY %s
+    %s
+)
+SELECT
+    p.id,
+    p.title,
+    p.description,
+    p.release_year,
+    p.director,
+    p.rating,
+    p.is_watched,
+    p.average_rating,
+    p.ratings_count,
+    p.trailer_url,
+    p.poster_url,
+    p.version,
+    p.updated_at,
+    %s,
+    %s
+FROM page p
+ORDER BY p.position`,
+		sortValuesSelect(watchListSortKeys),
+		orderByClause(watchListSortKeys),
+		where,
+		condition,
+		orderByClause(watchListSortKeys),
+		limit,
+		movieGenresSelect("p"),
+		sortValuesColumns(
+			"p",
+			len(watchListSortKeys),
+		),
+	)
 
 	rows, err := r.db.Query(
 		c,
 		sql,
+		params,
 	)
 	if err != nil {
-		return nil, err
+		return models.Page[models.Movie]{}, err
 	}
 	defer rows.Close()
 
 	movies := make(
-		[]*models.Movie,
+		[]models.Movie,
+		0,
+	)
+	sortValues := make(
+		[][]string,
 		0,
 	)
-	moviesMap := make(map[int]*models.Movie)
 
 	for rows.Next() {
 		var m models.Movie
-		var g models.Genre
+		values := make(
+			[]string,
+			len(watchListSortKeys),
+		)
 
-		err := rows.Scan(
+		dest := []any{
 			&m.Id,
 			&m.Title,
 			&m.Description,
@@ -65,51 +154,53 @@ ORDER BY wl.added_at ASC;
 			&m.Director,
 			&m.Rating,
 			&m.IsWatched,
+			&m.AverageRating,
+			&m.RatingsCount,
 			&m.TrailerUrl,
 			&m.PosterUrl,
-			&g.Id,
-			&g.Title,
-		)
-		if err != nil {
-			return nil, err
+			&m.Version,
+			&m.UpdatedAt,
+			&m.Genres,
 		}
-
-		if _, exists := moviesMap[m.Id]; !exists {
-			moviesMap[m.Id] = &m
-			movies = append(
-				movies,
-				&m,
+		for i := range values {
+			dest = append(
+				dest,
+				&values[i],
 			)
 		}
 
-		moviesMap[m.Id].Genres = append(
-			moviesMap[m.Id].Genres,
-			g,
+		err := rows.Scan(dest...)
+		if err != nil {
+			return models.Page[models.Movie]{}, err
+		}
+
+		movies = append(
+			movies,
+			m,
+		)
+		sortValues = append(
+			sortValues,
+			values,
 		)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, err
-	}
-
-	concreteMovies := make(
-		[]models.Movie,
-		0,
-		len(movies),
-	)
-	for _, v := range movies {
-		concreteMovies = append(
-			concreteMovies,
-			*v,
-		)
+		return models.Page[models.Movie]{}, err
 	}
 
-	return concreteMovies, nil
+	return buildPage(
+		watchListSortKeys,
+		page,
+		movies,
+		sortValues,
+		total,
+	), nil
 }
 
 func (r *WatchListRepository) Exists(
 	c context.Context,
+	userId int,
 	id int,
 ) (
 	bool,
@@ -118,31 +209,56 @@ func (r *WatchListRepository) Exists(
 	var exists bool
 	err := r.db.QueryRow(
 		c,
-		`SELECT EXISTS (SELECT 1 FROM watch_list WHERE movie_id = $1)`,
+		`SELECT EXISTS (
+    SELECT 1 FROM watch_list wl
+    JOIN movies m ON m.id = wl.movie_id
+    WHERE wl.user_id = $1 AND wl.movie_id = $2 AND m.deleted_at IS NULL
+)`,
+		userId,
 		id,
 	).Scan(&exists)
 	return exists, err
 }
 
+// Add puts an active movie on the user's watch list; adding it twice is not an error.
 func (r *WatchListRepository) Add(
 	c context.Context,
+	userId int,
 	id int,
 ) error {
-	_, err := r.db.Exec(
+	var movieExists bool
+	err := r.db.QueryRow(
 		c,
-		`INSERT INTO watch_list (movie_id) VALUES ($1)`,
+		`WITH movie AS (
+    SELECT m.id FROM movies m WHERE m.id = $2 AND m.deleted_at IS NULL
+), added AS (
+    INSERT INTO watch_list (user_id, movie_id)
+    SELECT $1::INT4, movie.id FROM movie
+    ON CONFLICT (user_id, movie_id) DO NOTHING
+)
+SELECT EXISTS (SELECT 1 FROM movie)`,
+		userId,
 		id,
-	)
-	return err
+	).Scan(&movieExists)
+	if err != nil {
+		return referencedNotFound(err, ErrMovieNotFound)
+	}
+	if !movieExists {
+		return ErrMovieNotFound
+	}
+
+	return nil
 }
 
 func (r *WatchListRepository) Delete(
 	c context.Context,
+	userId int,
 	id int,
 ) error {
 	_, err := r.db.Exec(
 		c,
-		`DELETE FROM watch_list WHERE movie_id = $1`,
+		`DELETE FROM watch_list WHERE user_id = $1 AND movie_id = $2`,
+		userId,
 		id,
 	)
 	return err
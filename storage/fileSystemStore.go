@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSystemStore keeps objects as files in a directory. Several replicas can only share it
+// through a shared volume; the S3 store is meant for that case.
+type FileSystemStore struct {
+	root string
+
+	// hashes caches content hashes, which would otherwise take a full read of the file on every Stat.
+	mu     sync.Mutex
+	hashes map[string]cachedHash
+}
+
+type cachedHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+func NewFileSystemStore(root string) (*FileSystemStore, error) {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSystemStore{
+		root:   root,
+		hashes: make(map[string]cachedHash),
+	}, nil
+}
+
+func (s *FileSystemStore) Put(c context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	// Writing to a temporary file first keeps readers from seeing partially written objects.
+	f, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, content)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(f.Name(), 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func (s *FileSystemStore) Get(c context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+
+	info, err := s.info(key, path, stat)
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+
+	return f, info, nil
+}
+
+func (s *FileSystemStore) Delete(c context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.hashes, key)
+	s.mu.Unlock()
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *FileSystemStore) Stat(c context.Context, key string) (ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return s.info(key, path, stat)
+}
+
+func (s *FileSystemStore) List(c context.Context) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]ObjectInfo, 0, len(entries))
+	for _, entry := range entries {
+		// Uploads in progress are hidden files and not objects yet.
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		stat, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:         entry.Name(),
+			Size:        stat.Size(),
+			ContentType: contentTypeOf(entry.Name()),
+			ModTime:     stat.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *FileSystemStore) PresignedURL(c context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+// path maps a key to a file below the root, rejecting keys that would escape it.
+func (s *FileSystemStore) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.root, key), nil
+}
+
+func (s *FileSystemStore) info(key string, path string, stat fs.FileInfo) (ObjectInfo, error) {
+	hash, err := s.hash(key, path, stat)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: contentTypeOf(key),
+		ModTime:     stat.ModTime(),
+		ETag:        hash,
+	}, nil
+}
+
+// hash returns the sha256 of the file, reusing the cached one while the size and modification time are unchanged.
+func (s *FileSystemStore) hash(key string, path string, stat fs.FileInfo) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.hashes[key]
+	s.mu.Unlock()
+
+	if ok && cached.size == stat.Size() && cached.modTime.Equal(stat.ModTime()) {
+		return cached.hash, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	s.mu.Lock()
+	s.hashes[key] = cachedHash{size: stat.Size(), modTime: stat.ModTime(), hash: hash}
+	s.mu.Unlock()
+
+	return hash, nil
+}
+
+// contentTypeOf derives the content type from the extension, since files carry no metadata.
+func contentTypeOf(key string) string {
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+
+	return contentType
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) (*FileSystemStore, string) {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "posters")
+	store, err := NewFileSystemStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, root
+}
+
+func put(t *testing.T, store BlobStore, key string, content string) {
+	t.Helper()
+
+	err := store.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSystemStorePutGet(t *testing.T) {
+	store, _ := newTestStore(t)
+	c := context.Background()
+
+	put(t, store, "poster.jpg", "first")
+
+	r, info, err := store.Get(c, "poster.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "first" {
+		t.Errorf("content %q, want %q", content, "first")
+	}
+	if info.Key != "poster.jpg" || info.Size != 5 || info.ContentType != "image/jpeg" || info.ETag == "" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	// Replacing the object changes its ETag, which clients revalidate against.
+	put(t, store, "poster.jpg", "second")
+
+	stat, err := store.Stat(c, "poster.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Size != 6 || stat.ETag == info.ETag {
+		t.Errorf("replaced object has size %d and ETag %q, previous ETag %q", stat.Size, stat.ETag, info.ETag)
+	}
+}
+
+func TestFileSystemStoreMissingObjects(t *testing.T) {
+	store, _ := newTestStore(t)
+	c := context.Background()
+
+	_, _, err := store.Get(c, "missing.jpg")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get: got %v, want ErrNotFound", err)
+	}
+
+	_, err = store.Stat(c, "missing.jpg")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat: got %v, want ErrNotFound", err)
+	}
+
+	err = store.Delete(c, "missing.jpg")
+	if err != nil {
+		t.Errorf("Delete: got %v, deleting a missing object is not an error", err)
+	}
+}
+
+func TestFileSystemStoreRejectsKeysOutsideRoot(t *testing.T) {
+	store, root := newTestStore(t)
+	c := context.Background()
+
+	for _, key := range []string{"", "../escape.jpg", "/etc/passwd", "a/../../escape.jpg"} {
+		err := store.Put(c, key, strings.NewReader("x"), 1, "")
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): got %v, want ErrInvalidKey", key, err)
+		}
+
+		_, _, err = store.Get(c, key)
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q): got %v, want ErrInvalidKey", key, err)
+		}
+	}
+
+	_, err := os.Stat(filepath.Join(filepath.Dir(root), "escape.jpg"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a file was written outside the root: %v", err)
+	}
+}
+
+func TestFileSystemStoreListAndDelete(t *testing.T) {
+	store, root := newTestStore(t)
+	c := context.Background()
+
+	put(t, store, "a.jpg", "a")
+	put(t, store, "b.png", "bb")
+
+	// Uploads in progress are hidden files and must not be listed.
+	err := os.WriteFile(filepath.Join(root, ".upload-123"), []byte("partial"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := store.List(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := make(map[string]int64)
+	for _, object := range objects {
+		sizes[object.Key] = object.Size
+	}
+	if len(sizes) != 2 || sizes["a.jpg"] != 1 || sizes["b.png"] != 2 {
+		t.Errorf("listed %v, want a.jpg and b.png", sizes)
+	}
+
+	err = store.Delete(c, "a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Stat(c, "a.jpg")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted object is still there: %v", err)
+	}
+}
+
+func TestFileSystemStoreCannotPresign(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	_, err := store.PresignedURL(context.Background(), "a.jpg", time.Minute)
+	if !errors.Is(err, ErrPresignUnsupported) {
+		t.Errorf("got %v, want ErrPresignUnsupported", err)
+	}
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps objects in a bucket of any S3-compatible service, e.g. AWS S3 or MinIO.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store connects to the service and creates the bucket when it does not exist yet.
+func NewS3Store(
+	c context.Context,
+	endpoint string,
+	region string,
+	bucket string,
+	accessKey string,
+	secretKey string,
+	useSSL bool,
+) (*S3Store, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(c, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		err = client.MakeBucket(c, bucket, minio.MakeBucketOptions{Region: region})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+func (s *S3Store) Put(c context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	_, err := s.client.PutObject(c, s.bucket, key, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (s *S3Store) Get(c context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error) {
+	if key == "" {
+		return nil, ObjectInfo{}, ErrInvalidKey
+	}
+
+	object, err := s.client.GetObject(c, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, s3Error(err)
+	}
+
+	// GetObject is lazy; Stat performs the request and reports missing objects.
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, ObjectInfo{}, s3Error(err)
+	}
+
+	return object, s3Info(stat), nil
+}
+
+func (s *S3Store) Delete(c context.Context, key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	return s.client.RemoveObject(c, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) Stat(c context.Context, key string) (ObjectInfo, error) {
+	if key == "" {
+		return ObjectInfo{}, ErrInvalidKey
+	}
+
+	stat, err := s.client.StatObject(c, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, s3Error(err)
+	}
+
+	return s3Info(stat), nil
+}
+
+func (s *S3Store) List(c context.Context) ([]ObjectInfo, error) {
+	objects := make([]ObjectInfo, 0)
+
+	for object := range s.client.ListObjects(c, s.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:     object.Key,
+			Size:    object.Size,
+			ModTime: object.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *S3Store) PresignedURL(c context.Context, key string, expires time.Duration) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+
+	presigned, err := s.client.PresignedGetObject(c, s.bucket, key, expires, url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	return presigned.String(), nil
+}
+
+func s3Error(err error) error {
+	response := minio.ToErrorResponse(err)
+	if response.Code == "NoSuchKey" || response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func s3Info(stat minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         stat.Key,
+		Size:        stat.Size,
+		ContentType: stat.ContentType,
+		ModTime:     stat.LastModified,
+		ETag:        strings.Trim(stat.ETag, `"`),
+	}
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound           = errors.New("blob not found")
+	ErrInvalidKey         = errors.New("invalid blob key")
+	ErrPresignUnsupported = errors.New("presigned urls are not supported by this store")
+)
+
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	// ETag is a hash of the content, without quotes, that changes whenever the content does.
+	ETag string
+}
+
+// BlobStore keeps binary objects, such as posters, under flat keys.
+// Implementations must be safe to share between API replicas.
+type BlobStore interface {
+	// Put stores the content under key, replacing any existing object. A negative size means unknown.
+	Put(c context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Get opens the object for reading; the caller closes it.
+	Get(c context.Context, key string) (io.ReadSeekCloser, ObjectInfo, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(c context.Context, key string) error
+	Stat(c context.Context, key string) (ObjectInfo, error)
+	// List returns every object in the store. ETags are not filled in, they may be costly to compute.
+	List(c context.Context) ([]ObjectInfo, error)
+	// PresignedURL returns a time-limited URL that allows reading the object without credentials.
+	PresignedURL(c context.Context, key string, expires time.Duration) (string, error)
+}